@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============ AUTO EXECUTE ============
+// Strategi di AUTO_EXECUTE_STRATEGIES langsung di-enqueue dengan AUTO_EXECUTE_LOTS.
+// Pesan Telegram tetap dikirim dengan tombol CANCEL yang berlaku sampai EA mengambil perintah.
+type autoExecuteEntry struct {
+	Trade     TradeCommand
+	ChatID    int64
+	MessageID int
+}
+
+var autoExecMu sync.Mutex
+var pendingAutoExecs = map[string]autoExecuteEntry{}
+
+func isAutoExecuteStrategy(strategy string) bool {
+	return config.AutoExecuteStrategies[strings.ToUpper(strategy)]
+}
+
+// buildOpenTrade - Susun perintah open dengan SL/TP dinamis (ATR) atau fixed sebagai fallback
+func buildOpenTrade(symbol, side string, price float64, strategy string, lots, atr float64) TradeCommand {
+	var sl, tp float64
+	if atr > 0 {
+		sl, tp = calculateDynamicSLTP(symbol, side, price, atr)
+	} else {
+		sl, tp = calculateSLTP(symbol, side, price)
+	}
+
+	return TradeCommand{
+		Action:   "open",
+		Symbol:   symbol,
+		Side:     side,
+		Lots:     lots,
+		Price:    price,
+		SL:       sl,
+		TP:       tp,
+		Strategy: strategy,
+	}
+}
+
+// buildAutoExecuteSignal - Pesan + tombol CANCEL untuk open signal auto execute.
+// Jika risk guard menolak, kembali ke keyboard manual dan trade = nil.
+func buildAutoExecuteSignal(p SignalPayload, ts string, lang string) (message, *TelegramInlineKeyboard, *TradeCommand) {
+	trade := buildOpenTrade(p.Symbol, p.Side, p.Price, p.Strategy, config.AutoExecuteLots, p.ATR)
+	trade.ID = newCommandID()
+
+	if err := checkRiskGuards(trade); err != nil {
+		log.Printf("🛡️ Auto execute blocked: %s %s strat=%s: %v", p.Symbol, p.Side, p.Strategy, err)
+		publishEvent(eventRiskBreached, riskEvent{Source: "auto_execute", Reason: err.Error(), Trade: trade})
+		msg := templateMessage("auto_blocked", messageData{SignalPayload: p, Lang: lang, Time: ts, Note: err.Error(), Buttons: true})
+		return msg, openSignalButtons(p), nil
+	}
+
+	msg := templateMessage("auto_execute", messageData{
+		SignalPayload: p, Lang: lang, Time: ts, Lots: trade.Lots, SL: trade.SL, TP: trade.TP, Buttons: true,
+	})
+	buttons := &TelegramInlineKeyboard{
+		InlineKeyboard: [][]TelegramInlineButton{
+			{
+				{Text: "❌ CANCEL", CallbackData: "cancel|" + trade.ID},
+			},
+		},
+	}
+	return msg, buttons, &trade
+}
+
+// enqueueAutoExecute - Catat pesan Telegram lalu enqueue perintah untuk EA. Jadwal trading dan
+// MAX_PENDING_OPENS dicek ulang (pesan bisa tertahan di outbox sampai blackout dimulai).
+func enqueueAutoExecute(trade TradeCommand, chatID int64, messageID int) error {
+	if reason := tradingBlockedReason(time.Now()); reason != "" {
+		log.Printf("⛔ Auto execute refused: %s %s strat=%s: %s", trade.Symbol, trade.Side, trade.Strategy, reason)
+		go markMessageOutcome(chatID, messageID, "", "⛔ Auto trade blocked: "+reason)
+		return errors.New(reason)
+	}
+
+	autoExecMu.Lock()
+	pendingAutoExecs[trade.ID] = autoExecuteEntry{Trade: trade, ChatID: chatID, MessageID: messageID}
+	autoExecMu.Unlock()
+
+	if err := enqueueTradeGuarded(trade); err != nil {
+		autoExecMu.Lock()
+		delete(pendingAutoExecs, trade.ID)
+		autoExecMu.Unlock()
+
+		log.Printf("🛡️ Auto execute blocked at enqueue: %s %s strat=%s: %v", trade.Symbol, trade.Side, trade.Strategy, err)
+		if err != errQueueClosed {
+			publishEvent(eventRiskBreached, riskEvent{Source: "auto_execute", Reason: err.Error(), Trade: trade})
+		}
+		go markMessageOutcome(chatID, messageID, "", "🛡️ Auto trade blocked: "+err.Error())
+		return err
+	}
+	log.Printf("🤖 Auto execute: %s %s %.2f lots strat=%s id=%s", trade.Symbol, trade.Side, trade.Lots, trade.Strategy, trade.ID)
+	return nil
+}
+
+// markAutoExecutePickedUp - Dipanggil setelah EA mengambil perintah; tombol CANCEL tidak berlaku lagi
+func markAutoExecutePickedUp(cmds []TradeCommand) {
+	var picked []autoExecuteEntry
+
+	autoExecMu.Lock()
+	for _, cmd := range cmds {
+		if entry, ok := pendingAutoExecs[cmd.ID]; ok {
+			picked = append(picked, entry)
+			delete(pendingAutoExecs, cmd.ID)
+		}
+	}
+	autoExecMu.Unlock()
+
+	for _, entry := range picked {
+		log.Printf("🤖 Auto execute picked up by EA: id=%s", entry.Trade.ID)
+		go markMessageOutcome(entry.ChatID, entry.MessageID, "", "📥 Picked up by EA")
+	}
+}
+
+func handleAutoExecuteCancel(callback *TelegramCallbackQuery, id string) {
+	autoExecMu.Lock()
+	delete(pendingAutoExecs, id)
+	autoExecMu.Unlock()
+
+	trade, ok := cancelQueuedCommand(id)
+	if !ok {
+		answerCallbackQuery(callback.ID, "⚠️ Too late, EA already picked up the order")
+		log.Printf("⚠️ Auto execute cancel too late: id=%s", id)
+		markCallbackOutcome(callback, "📥 Picked up by EA before cancel")
+		return
+	}
+
+	answerCallbackQuery(callback.ID, "🚫 Auto trade cancelled")
+	log.Printf("🚫 Auto execute cancelled by user %d: id=%s", callback.From.ID, id)
+	journal.MarkCommandCancelled(id, callback.From.ID)
+	markCallbackOutcome(callback, fmt.Sprintf("🚫 Auto trade %.2f lots cancelled by %s", trade.Lots, userLabel(callback)))
+}
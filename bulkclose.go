@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// ============ BULK CLOSE ============
+// Close banyak order sekaligus lewat EA: closeall / closelosers / closewinners,
+// masing-masing dengan filter symbol dan strategi opsional.
+// Telegram: /closeall → menu tombol → konfirmasi → enqueue.
+// HTTP darurat: POST /close-all?token=... (langsung enqueue, tanpa konfirmasi).
+
+var bulkCloseActions = map[string]string{
+	"all":     "closeall",
+	"losers":  "closelosers",
+	"winners": "closewinners",
+}
+
+type bulkCloseRequest struct {
+	Mode     string `json:"mode"` // all | losers | winners
+	Symbol   string `json:"symbol,omitempty"`
+	Strategy string `json:"strategy,omitempty"`
+}
+
+func (r bulkCloseRequest) describe() string {
+	desc := "ALL orders"
+	switch r.Mode {
+	case "losers":
+		desc = "LOSING orders"
+	case "winners":
+		desc = "WINNING orders"
+	}
+	if r.Symbol != "" {
+		desc += " on " + r.Symbol
+	}
+	if r.Strategy != "" {
+		desc += " from " + r.Strategy
+	}
+	return desc
+}
+
+// callbackData - mode|symbol|strategy (dipakai tombol menu dan konfirmasi)
+func (r bulkCloseRequest) callbackData(prefix string) string {
+	return strings.Join([]string{prefix, r.Mode, r.Symbol, r.Strategy}, "|")
+}
+
+func parseBulkCloseCallback(parts []string) bulkCloseRequest {
+	var r bulkCloseRequest
+	if len(parts) > 1 {
+		r.Mode = parts[1]
+	}
+	if len(parts) > 2 {
+		r.Symbol = parts[2]
+	}
+	if len(parts) > 3 {
+		r.Strategy = parts[3]
+	}
+	return r
+}
+
+// enqueueBulkClose - Validasi mode lalu kirim ke queue EA
+func enqueueBulkClose(r bulkCloseRequest, source string, userID int64) (TradeCommand, error) {
+	action, ok := bulkCloseActions[r.Mode]
+	if !ok {
+		return TradeCommand{}, fmt.Errorf("unknown mode %q (all, losers, winners)", r.Mode)
+	}
+	cmd := TradeCommand{
+		Action:   action,
+		Symbol:   strings.ToUpper(r.Symbol),
+		Strategy: strings.ToUpper(r.Strategy),
+		ID:       newCommandID(),
+	}
+	if err := enqueueTrade(cmd); err != nil {
+		log.Printf("⚠️ Bulk close refused: %s (source=%s user=%d): %v", r.describe(), source, userID, err)
+		return TradeCommand{}, err
+	}
+	journal.RecordCommand(cmd, 0, source, userID)
+	log.Printf("🔴 Bulk close queued: %s (source=%s user=%d)", r.describe(), source, userID)
+	return cmd, nil
+}
+
+// bulkCloseMenu - Tombol pilihan: semua / losers / winners, lalu per symbol dan strategi yang masih open
+func bulkCloseMenu(symbol string) *TelegramInlineKeyboard {
+	base := bulkCloseRequest{Symbol: symbol}
+	withMode := func(mode string) bulkCloseRequest { r := base; r.Mode = mode; return r }
+
+	rows := [][]TelegramInlineButton{
+		{
+			{Text: "🔴 Close ALL", CallbackData: withMode("all").callbackData("bulk")},
+		},
+		{
+			{Text: "📉 Losers", CallbackData: withMode("losers").callbackData("bulk")},
+			{Text: "📈 Winners", CallbackData: withMode("winners").callbackData("bulk")},
+		},
+	}
+
+	if symbol == "" && journal != nil {
+		if open, err := journal.OpenTrades(); err == nil {
+			symbols, strategies := map[string]bool{}, map[string]bool{}
+			for _, t := range open {
+				symbols[t.Symbol] = true
+				if t.Strategy != "" {
+					strategies[strings.ToUpper(t.Strategy)] = true
+				}
+			}
+			var row []TelegramInlineButton
+			for _, s := range sortedKeys(symbols) {
+				row = append(row, TelegramInlineButton{Text: "💱 " + s, CallbackData: bulkCloseRequest{Mode: "all", Symbol: s}.callbackData("bulk")})
+			}
+			for _, s := range sortedKeys(strategies) {
+				row = append(row, TelegramInlineButton{Text: "🎯 " + s, CallbackData: bulkCloseRequest{Mode: "all", Strategy: s}.callbackData("bulk")})
+			}
+			for len(row) > 0 {
+				n := 3
+				if len(row) < n {
+					n = len(row)
+				}
+				rows = append(rows, row[:n])
+				row = row[n:]
+			}
+		}
+	}
+
+	rows = append(rows, []TelegramInlineButton{{Text: "✖️ Cancel", CallbackData: "bulkno"}})
+	return &TelegramInlineKeyboard{InlineKeyboard: rows}
+}
+
+func bulkCloseConfirmButtons(r bulkCloseRequest) *TelegramInlineKeyboard {
+	return &TelegramInlineKeyboard{
+		InlineKeyboard: [][]TelegramInlineButton{
+			{
+				{Text: "✅ Confirm", CallbackData: r.callbackData("bulkok")},
+				{Text: "✖️ Cancel", CallbackData: "bulkno"},
+			},
+		},
+	}
+}
+
+// ============ TELEGRAM: /closeall ============
+// /closeall                             → menu tombol
+// /closeall [symbol] [losers|winners] [strategy=X] → langsung ke konfirmasi
+func handleCloseAllCommand(from TelegramUser, chatID int64, args []string) {
+	if len(args) == 0 {
+		sendTelegramWithButtonsTo(chatID, "🔴 Bulk close — pilih order yang akan ditutup:", bulkCloseMenu(""))
+		return
+	}
+
+	r := bulkCloseRequest{Mode: "all"}
+	for _, arg := range args {
+		lower := strings.ToLower(arg)
+		switch {
+		case lower == "all" || lower == "losers" || lower == "winners":
+			r.Mode = lower
+		case strings.HasPrefix(lower, "strategy="):
+			r.Strategy = strings.ToUpper(arg[len("strategy="):])
+		default:
+			r.Symbol = strings.ToUpper(arg)
+		}
+	}
+	sendTelegramWithButtonsTo(chatID, fmt.Sprintf("⚠️ Close %s?", r.describe()), bulkCloseConfirmButtons(r))
+}
+
+// handleBulkCloseCallback - bulk (pilih → konfirmasi), bulkok (enqueue), bulkno (batal)
+func handleBulkCloseCallback(callback *TelegramCallbackQuery, action string, parts []string) {
+	chatID, messageID := callback.Message.Chat.ID, callback.Message.MessageID
+	r := parseBulkCloseCallback(parts)
+
+	switch action {
+	case "bulk":
+		answerCallbackQuery(callback.ID, "Confirm bulk close")
+		if err := editMessage(chatID, messageID, fmt.Sprintf("⚠️ Close %s?", r.describe()), bulkCloseConfirmButtons(r)); err != nil {
+			log.Printf("⚠️ editMessage error: %v", err)
+		}
+
+	case "bulkok":
+		if _, err := enqueueBulkClose(r, "manual", callback.From.ID); err != nil {
+			answerCallbackQuery(callback.ID, "❌ "+err.Error())
+			return
+		}
+		answerCallbackQuery(callback.ID, "🔴 Bulk close sent!")
+		if err := editMessageText(chatID, messageID, fmt.Sprintf("🔴 Close %s queued by %s", r.describe(), userLabel(callback))); err != nil {
+			log.Printf("⚠️ editMessageText error: %v", err)
+		}
+
+	case "bulkno":
+		answerCallbackQuery(callback.ID, "Cancelled")
+		if err := editMessageText(chatID, messageID, "✖️ Bulk close cancelled by "+userLabel(callback)); err != nil {
+			log.Printf("⚠️ editMessageText error: %v", err)
+		}
+	}
+}
+
+// ============ HTTP: BULK CLOSE ============
+// POST /close-all?token=...  body {"mode":"all|losers|winners","symbol":"","strategy":""}
+// (atau query ?mode=&symbol=&strategy=) - untuk darurat, tanpa konfirmasi
+func closeAllHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !authorizeAPIRequest(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	req := bulkCloseRequest{Mode: query.Get("mode"), Symbol: query.Get("symbol"), Strategy: query.Get("strategy")}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid json"))
+			return
+		}
+	}
+	req.Mode = getOr(strings.ToLower(req.Mode), "all")
+
+	cmd, err := enqueueBulkClose(req, "api", 0)
+	if err == errQueueClosed {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	sendTelegram(fmt.Sprintf("🚨 Emergency close via API: %s", req.describe()))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "command": cmd})
+}
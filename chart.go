@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// ============ CHARTS ============
+// Render PNG sederhana (pure Go) dari journal: equity curve, P&L harian,
+// dan cumulative R per strategi. Dikirim via sendPhoto (/chart dan laporan mingguan).
+
+const (
+	chartWidth  = 800
+	chartHeight = 450
+	chartLeft   = 80
+	chartRight  = 20
+	chartTop    = 40
+	chartBottom = 50
+)
+
+var (
+	chartBackground = color.RGBA{255, 255, 255, 255}
+	chartAxis       = color.RGBA{60, 60, 60, 255}
+	chartGrid       = color.RGBA{225, 225, 225, 255}
+	chartText       = color.RGBA{30, 30, 30, 255}
+	chartProfit     = color.RGBA{46, 160, 67, 255}
+	chartLoss       = color.RGBA{218, 54, 51, 255}
+	chartPalette    = []color.RGBA{
+		{31, 119, 180, 255}, {255, 127, 14, 255}, {44, 160, 44, 255}, {214, 39, 40, 255},
+		{148, 103, 189, 255}, {140, 86, 75, 255}, {227, 119, 194, 255}, {127, 127, 127, 255},
+	}
+)
+
+type chartSeries struct {
+	Name   string
+	Values []float64
+	Color  color.RGBA
+}
+
+type chartCanvas struct {
+	img        *image.RGBA
+	minY, maxY float64
+}
+
+// newChartCanvas - Background, judul, grid dan label sumbu Y
+func newChartCanvas(title string, minY, maxY float64) *chartCanvas {
+	minY, maxY = math.Min(minY, 0), math.Max(maxY, 0)
+	if maxY-minY < 1e-9 {
+		minY, maxY = minY-1, maxY+1
+	}
+	pad := (maxY - minY) * 0.08
+	c := &chartCanvas{
+		img:  image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight)),
+		minY: minY - pad,
+		maxY: maxY + pad,
+	}
+	draw.Draw(c.img, c.img.Bounds(), &image.Uniform{chartBackground}, image.Point{}, draw.Src)
+	c.text(chartLeft, 24, title, chartText)
+
+	const ticks = 5
+	for i := 0; i <= ticks; i++ {
+		v := c.minY + (c.maxY-c.minY)*float64(i)/ticks
+		y := c.yPix(v)
+		c.line(chartLeft, y, chartWidth-chartRight, y, chartGrid, 1)
+		c.text(8, y+4, formatChartValue(v), chartText)
+	}
+
+	// Sumbu dan garis nol
+	c.line(chartLeft, chartTop, chartLeft, chartHeight-chartBottom, chartAxis, 1)
+	c.line(chartLeft, c.yPix(0), chartWidth-chartRight, c.yPix(0), chartAxis, 1)
+	return c
+}
+
+func formatChartValue(v float64) string {
+	if math.Abs(v) >= 100 {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func (c *chartCanvas) yPix(v float64) int {
+	plot := float64(chartHeight - chartTop - chartBottom)
+	return chartHeight - chartBottom - int((v-c.minY)/(c.maxY-c.minY)*plot)
+}
+
+// xPix - Posisi titik ke-i dari n titik
+func (c *chartCanvas) xPix(i, n int) int {
+	plot := chartWidth - chartLeft - chartRight
+	if n <= 1 {
+		return chartLeft + plot/2
+	}
+	return chartLeft + i*plot/(n-1)
+}
+
+func (c *chartCanvas) text(x, y int, s string, col color.Color) {
+	d := &font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(col),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	d.DrawString(s)
+}
+
+// line - Bresenham dengan ketebalan sederhana
+func (c *chartCanvas) line(x0, y0, x1, y1 int, col color.Color, thickness int) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		for t := 0; t < thickness; t++ {
+			c.img.Set(x0, y0+t, col)
+			c.img.Set(x0+t, y0, col)
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func (c *chartCanvas) xLabels(labels []string, positions func(i int) int) {
+	step := 1
+	if len(labels) > 8 {
+		step = (len(labels) + 7) / 8
+	}
+	for i := 0; i < len(labels); i += step {
+		x := positions(i) - len(labels[i])*7/2
+		c.text(x, chartHeight-chartBottom+18, labels[i], chartText)
+	}
+}
+
+func (c *chartCanvas) legend(series []chartSeries) {
+	x := chartLeft
+	for _, s := range series {
+		draw.Draw(c.img, image.Rect(x, chartHeight-20, x+12, chartHeight-10), &image.Uniform{s.Color}, image.Point{}, draw.Src)
+		c.text(x+16, chartHeight-10, s.Name, chartText)
+		x += 16 + len(s.Name)*7 + 20
+	}
+}
+
+func (c *chartCanvas) png() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, c.img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderLineChart - Semua series memakai sumbu X yang sama (len(labels) titik)
+func renderLineChart(title string, labels []string, series []chartSeries) ([]byte, error) {
+	minY, maxY := 0.0, 0.0
+	for _, s := range series {
+		for _, v := range s.Values {
+			minY, maxY = math.Min(minY, v), math.Max(maxY, v)
+		}
+	}
+
+	c := newChartCanvas(title, minY, maxY)
+	n := len(labels)
+	for _, s := range series {
+		for i := 1; i < len(s.Values); i++ {
+			c.line(c.xPix(i-1, n), c.yPix(s.Values[i-1]), c.xPix(i, n), c.yPix(s.Values[i]), s.Color, 2)
+		}
+	}
+	c.xLabels(labels, func(i int) int { return c.xPix(i, n) })
+	if len(series) > 1 {
+		c.legend(series)
+	}
+	return c.png()
+}
+
+func renderBarChart(title string, labels []string, values []float64) ([]byte, error) {
+	minY, maxY := 0.0, 0.0
+	for _, v := range values {
+		minY, maxY = math.Min(minY, v), math.Max(maxY, v)
+	}
+
+	c := newChartCanvas(title, minY, maxY)
+	plot := chartWidth - chartLeft - chartRight
+	slot := plot / maxInt(len(values), 1)
+	barWidth := maxInt(slot*6/10, 2)
+	center := func(i int) int { return chartLeft + slot*i + slot/2 }
+
+	for i, v := range values {
+		col := chartProfit
+		if v < 0 {
+			col = chartLoss
+		}
+		top, bottom := c.yPix(math.Max(v, 0)), c.yPix(math.Min(v, 0))
+		if bottom == top {
+			bottom++
+		}
+		draw.Draw(c.img, image.Rect(center(i)-barWidth/2, top, center(i)+barWidth/2, bottom), &image.Uniform{col}, image.Point{}, draw.Src)
+	}
+	c.xLabels(labels, center)
+	return c.png()
+}
+
+// ---- Data dari journal ----
+
+func renderEquityChart(trades []closedTrade) ([]byte, error) {
+	labels := []string{"start"}
+	equity := []float64{0}
+	loc := reportLocation()
+	for _, t := range trades {
+		equity = append(equity, equity[len(equity)-1]+t.Profit)
+		labels = append(labels, time.Unix(t.ClosedAt, 0).In(loc).Format("01/02"))
+	}
+	title := fmt.Sprintf("Equity curve (%d trades, net %+.2f)", len(trades), equity[len(equity)-1])
+	return renderLineChart(title, labels, []chartSeries{{Name: "Equity", Values: equity, Color: chartPalette[0]}})
+}
+
+func renderDailyPnLChart(trades []closedTrade) ([]byte, error) {
+	loc := reportLocation()
+	daily := map[string]float64{}
+	for _, t := range trades {
+		daily[time.Unix(t.ClosedAt, 0).In(loc).Format("2006-01-02")] += t.Profit
+	}
+
+	days := make([]string, 0, len(daily))
+	for day := range daily {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	labels := make([]string, len(days))
+	values := make([]float64, len(days))
+	for i, day := range days {
+		labels[i] = day[5:] // MM-DD
+		values[i] = daily[day]
+	}
+	return renderBarChart("Daily P&L", labels, values)
+}
+
+// renderStrategyRChart - Cumulative R per strategi; sumbu X = urutan trade (semua strategi)
+func renderStrategyRChart(trades []closedTrade) ([]byte, error) {
+	var strategies []string
+	index := map[string]int{}
+	for _, t := range trades {
+		if _, ok := t.rMultiple(); ok {
+			if _, seen := index[t.Strategy]; !seen {
+				index[t.Strategy] = len(strategies)
+				strategies = append(strategies, t.Strategy)
+			}
+		}
+	}
+
+	series := make([]chartSeries, len(strategies))
+	for i, name := range strategies {
+		series[i] = chartSeries{Name: name, Values: []float64{0}, Color: chartPalette[i%len(chartPalette)]}
+	}
+	labels := []string{"0"}
+	for _, t := range trades {
+		r, ok := t.rMultiple()
+		if !ok {
+			continue
+		}
+		for i := range series {
+			last := series[i].Values[len(series[i].Values)-1]
+			if strings.EqualFold(series[i].Name, t.Strategy) {
+				last += r
+			}
+			series[i].Values = append(series[i].Values, last)
+		}
+		labels = append(labels, strconv.Itoa(len(labels)))
+	}
+	return renderLineChart("Cumulative R by strategy", labels, series)
+}
+
+// sendCharts - Render dan kirim chart (equity, daily, strategy) untuk rentang waktu ke chatID
+func sendCharts(chatID string, kinds []string, from, to time.Time) error {
+	if journal == nil {
+		return fmt.Errorf("journal disabled")
+	}
+	trades, err := journal.ClosedTrades(from.Unix(), to.Unix())
+	if err != nil {
+		return err
+	}
+	if len(trades) == 0 {
+		return fmt.Errorf("no closed trades in range")
+	}
+
+	for _, kind := range kinds {
+		var img []byte
+		switch kind {
+		case "equity":
+			img, err = renderEquityChart(trades)
+		case "daily":
+			img, err = renderDailyPnLChart(trades)
+		case "strategy":
+			img, err = renderStrategyRChart(trades)
+		default:
+			return fmt.Errorf("unknown chart %q (equity, daily, strategy)", kind)
+		}
+		if err != nil {
+			return err
+		}
+		if err := sendTelegramFile(chatID, "sendPhoto", "photo", kind+".png", img, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ============ TELEGRAM: /chart ============
+// /chart [equity|daily|strategy] [hari] - tanpa jenis = kirim ketiganya, default 30 hari
+func handleChartCommand(chatID int64, args []string) {
+	kinds := []string{"equity", "daily", "strategy"}
+	days := 30
+	for _, arg := range args {
+		if d, err := strconv.Atoi(arg); err == nil && d > 0 {
+			days = d
+		} else {
+			kinds = []string{strings.ToLower(arg)}
+		}
+	}
+
+	to := time.Now()
+	if err := sendCharts(strconv.FormatInt(chatID, 10), kinds, to.AddDate(0, 0, -days), to); err != nil {
+		log.Printf("⚠️ chart error: %v", err)
+		sendTelegramTo(chatID, "⚠️ Chart unavailable: "+err.Error())
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============ TELEGRAM COMMAND ROUTER ============
+// Semua command teks didaftarkan di botCommands: nama, argumen, role minimum dan handler.
+// Role diambil dari TELEGRAM_ADMIN_IDS / TELEGRAM_TRADER_IDS; jika keduanya kosong
+// hanya anggota TELEGRAM_CHAT_ID yang admin, chat lain viewer. Pesan dari chat yang
+// tidak dikenal (lihat allowedChat) diabaikan. Balasan command dikirim ke chat asal.
+// Daftar command didaftarkan ke Telegram lewat setMyCommands saat startup.
+
+type botRole int
+
+const (
+	roleViewer botRole = iota // hanya baca: status, statistik, laporan
+	roleTrader                // boleh mengirim perintah trading
+	roleAdmin                 // boleh pause/resume dan mengubah strategi
+)
+
+func (r botRole) String() string {
+	switch r {
+	case roleAdmin:
+		return "admin"
+	case roleTrader:
+		return "trader"
+	default:
+		return "viewer"
+	}
+}
+
+type TelegramUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username,omitempty"`
+}
+
+// TelegramIncomingMessage - Pesan teks yang masuk lewat webhook
+type TelegramIncomingMessage struct {
+	MessageID int          `json:"message_id"`
+	From      TelegramUser `json:"from"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
+	Text           string                   `json:"text"`
+	ReplyToMessage *TelegramIncomingMessage `json:"reply_to_message,omitempty"`
+}
+
+type botCommand struct {
+	Name        string
+	Usage       string
+	Description string
+	Role        botRole
+	Handler     func(from TelegramUser, chatID int64, args []string) // chatID = chat asal, tujuan balasan
+}
+
+var botCommands []botCommand
+
+func init() {
+	botCommands = []botCommand{
+		{"help", "", "Daftar command", roleViewer, handleHelpCommand},
+		{"orders", "", "Order aktif dari EA", roleViewer, handleOrdersCommand},
+		{"status", "", "Order aktif dari EA", roleViewer, handleOrdersCommand},
+		{"balance", "", "Balance, equity dan margin akun", roleViewer, handleBalanceCommand},
+		{"pnl", "[today|week|month]", "Realized P&L", roleViewer, handlePnLCommand},
+		{"risk", "", "Risk limit dan exposure saat ini", roleViewer, handleRiskCommand},
+		{"stats", "[days]", "Statistik per strategi", roleViewer, func(_ TelegramUser, chatID int64, args []string) { handleStatsCommand(chatID, args) }},
+		{"chart", "[equity|daily|strategy] [days]", "Chart PNG", roleViewer, func(_ TelegramUser, chatID int64, args []string) { handleChartCommand(chatID, args) }},
+		{"export", "[signals|commands|trades] [csv|json] ...", "Export journal", roleViewer, func(_ TelegramUser, chatID int64, args []string) { handleExportCommand(chatID, args) }},
+		{"strategies", "[enable|disable NAME]", "Lihat / aktifkan / matikan strategi", roleViewer, handleStrategiesCommand},
+		{"settings", "", "Preset lot, risiko, SL/TP, notifikasi, timezone", roleViewer, handleSettingsCommand},
+		{"preview", "[template] [id|en]", "Preview template pesan", roleViewer, handlePreviewCommand},
+		{"close", "<ticket>", "Close satu order", roleTrader, handleCloseCommand},
+		{"closeall", "[symbol] [losers|winners] [strategy=X]", "Close banyak order (dengan konfirmasi)", roleTrader, handleCloseAllCommand},
+		{"pause", "", "Berhenti menerima open signal", roleAdmin, handlePauseCommand},
+		{"resume", "", "Terima open signal lagi", roleAdmin, handleResumeCommand},
+	}
+}
+
+// userRole - Role user berdasarkan TELEGRAM_ADMIN_IDS / TELEGRAM_TRADER_IDS. Tanpa keduanya
+// hanya chat TELEGRAM_CHAT_ID yang admin; chat lain (route, DM) cukup viewer.
+func userRole(userID, chatID int64) botRole {
+	if len(config.TelegramAdminIDs) == 0 && len(config.TelegramTraderIDs) == 0 {
+		if strconv.FormatInt(chatID, 10) == config.TelegramChatID {
+			return roleAdmin
+		}
+		return roleViewer
+	}
+	id := strconv.FormatInt(userID, 10)
+	switch {
+	case config.TelegramAdminIDs[id]:
+		return roleAdmin
+	case config.TelegramTraderIDs[id]:
+		return roleTrader
+	default:
+		return roleViewer
+	}
+}
+
+// allowedChat - Chat yang boleh mengirim command / menekan tombol: TELEGRAM_CHAT_ID, chat
+// Telegram di NOTIFY_ROUTES, dan DM user yang terdaftar di TELEGRAM_ADMIN_IDS / TELEGRAM_TRADER_IDS
+func allowedChat(chatID, userID int64) bool {
+	id := strconv.FormatInt(chatID, 10)
+	if id == config.TelegramChatID {
+		return true
+	}
+	for _, r := range notifyRoutes {
+		if t, ok := r.Target.(telegramNotifier); ok && t.ChatID == id {
+			return true
+		}
+	}
+	user := strconv.FormatInt(userID, 10)
+	return chatID == userID && (config.TelegramAdminIDs[user] || config.TelegramTraderIDs[user])
+}
+
+// parseCommand - "/close@MyBot 123" → ("close", ["123"]); ok=false jika bukan command
+func parseCommand(text string) (string, []string, bool) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", nil, false
+	}
+	name := strings.ToLower(strings.TrimPrefix(fields[0], "/"))
+	if at := strings.Index(name, "@"); at >= 0 {
+		name = name[:at]
+	}
+	return name, fields[1:], true
+}
+
+// handleTextCommand - Router untuk pesan teks dari webhook
+func handleTextCommand(msg *TelegramIncomingMessage) {
+	name, args, ok := parseCommand(msg.Text)
+	if !ok {
+		log.Printf("💬 Non-callback message received: %q", msg.Text)
+		return
+	}
+
+	for _, cmd := range botCommands {
+		if cmd.Name != name {
+			continue
+		}
+		if role := userRole(msg.From.ID, msg.Chat.ID); role < cmd.Role {
+			log.Printf("🔒 /%s denied for user %d in chat %d (role=%s, need %s)", name, msg.From.ID, msg.Chat.ID, role, cmd.Role)
+			sendTelegramTo(msg.Chat.ID, fmt.Sprintf("🔒 /%s requires %s role", name, cmd.Role))
+			return
+		}
+		log.Printf("⌨️  /%s %v by user %d in chat %d", name, args, msg.From.ID, msg.Chat.ID)
+		cmd.Handler(msg.From, msg.Chat.ID, args)
+		return
+	}
+	sendTelegramTo(msg.Chat.ID, fmt.Sprintf("❓ Unknown command /%s — see /help", name))
+}
+
+// registerBotCommands - setMyCommands agar muncul di menu Telegram
+func registerBotCommands() {
+	type command struct {
+		Command     string `json:"command"`
+		Description string `json:"description"`
+	}
+	list := make([]command, 0, len(botCommands))
+	for _, cmd := range botCommands {
+		list = append(list, command{Command: cmd.Name, Description: cmd.Description})
+	}
+
+	b, _ := json.Marshal(map[string]interface{}{"commands": list})
+	resp, err := telegramPost("setMyCommands", "application/json", bytes.NewReader(b))
+	if err != nil {
+		log.Printf("⚠️ setMyCommands error: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		log.Printf("⚠️ setMyCommands error: %v", parseTelegramError(resp.StatusCode, resp.Body))
+		return
+	}
+	log.Printf("⌨️  Registered %d bot commands", len(list))
+}
+
+// ============ SIGNAL INTAKE: PAUSE & STRATEGIES ============
+
+var signalsPaused atomic.Bool
+
+var strategiesMu sync.Mutex
+var disabledStrategies = map[string]bool{}
+
+func isStrategyDisabled(strategy string) bool {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	return disabledStrategies[strings.ToUpper(strategy)]
+}
+
+func setStrategyEnabled(strategy string, enabled bool) {
+	strategiesMu.Lock()
+	defer strategiesMu.Unlock()
+	if enabled {
+		delete(disabledStrategies, strings.ToUpper(strategy))
+	} else {
+		disabledStrategies[strings.ToUpper(strategy)] = true
+	}
+}
+
+// ============ COMMAND HANDLERS ============
+
+func handleHelpCommand(from TelegramUser, chatID int64, args []string) {
+	role := userRole(from.ID, chatID)
+	var b strings.Builder
+	fmt.Fprintf(&b, "🤖 Commands (role: %s)\n", role)
+	for _, cmd := range botCommands {
+		if role < cmd.Role {
+			continue
+		}
+		usage := "/" + cmd.Name
+		if cmd.Usage != "" {
+			usage += " " + cmd.Usage
+		}
+		fmt.Fprintf(&b, "• %s — %s\n", usage, cmd.Description)
+	}
+	sendTelegramTo(chatID, strings.TrimRight(b.String(), "\n"))
+}
+
+func handleOrdersCommand(from TelegramUser, chatID int64, args []string) {
+	enqueueStatus()
+	sendTelegramTo(chatID, "📋 Fetching active orders...")
+}
+
+func handleBalanceCommand(from TelegramUser, chatID int64, args []string) {
+	queueMu.Lock()
+	commandQueue = append(commandQueue, TradeCommand{Action: "account", enqueuedAt: time.Now()})
+	queueMu.Unlock()
+	sendTelegramTo(chatID, "💰 Fetching account info...")
+}
+
+func handlePnLCommand(from TelegramUser, chatID int64, args []string) {
+	if journal == nil {
+		sendTelegramTo(chatID, "⚠️ Journal disabled, P&L unavailable")
+		return
+	}
+
+	period := "today"
+	if len(args) > 0 {
+		period = strings.ToLower(args[0])
+	}
+	now := time.Now().In(reportLocation())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	var start time.Time
+	switch period {
+	case "today":
+		start = today
+	case "week":
+		start = today.AddDate(0, 0, -7)
+	case "month":
+		start = today.AddDate(0, -1, 0)
+	default:
+		sendTelegramTo(chatID, "Usage: /pnl [today|week|month]")
+		return
+	}
+
+	report, err := journal.BuildStatsReport(start, now)
+	if err != nil {
+		log.Printf("❌ pnl error: %v", err)
+		sendTelegramTo(chatID, "❌ Failed to compute P&L")
+		return
+	}
+	o := report.Overall
+	msg := fmt.Sprintf("💵 P&L %s: %+.2f\n📊 %d trades (W %d / L %d)", period, o.NetProfit, o.Trades, o.Wins, o.Losses)
+	for _, s := range report.ByStrategy {
+		if s.Trades > 0 {
+			msg += fmt.Sprintf("\n• %s: %+.2f (%d tr)", s.Key, s.NetProfit, s.Trades)
+		}
+	}
+	sendTelegramTo(chatID, msg)
+}
+
+func handleRiskCommand(from TelegramUser, chatID int64, args []string) {
+	limit := func(v float64) string {
+		if v <= 0 {
+			return "off"
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	var b strings.Builder
+	b.WriteString("🛡️ Risk\n")
+	fmt.Fprintf(&b, "📦 MAX_LOT_SIZE: %s\n", limit(config.MaxLotSize))
+	fmt.Fprintf(&b, "⏳ Queued opens: %d / %s\n", countQueuedOpens(), limit(float64(config.MaxPendingOpens)))
+	fmt.Fprintf(&b, "🎯 SL x%.2f ATR | TP x%.2f ATR\n", volConfig.SLMultiplier, volConfig.TPMultiplier)
+	fmt.Fprintf(&b, "✋ Confirm above: %s lot | %s%% risk\n", limit(config.ConfirmLotsAbove), limit(config.ConfirmRiskPct))
+	if balance, currency := currentBalance(); balance > 0 {
+		fmt.Fprintf(&b, "🏦 Balance: %.2f %s\n", balance, currency)
+	}
+	if len(config.AutoExecuteStrategies) > 0 {
+		fmt.Fprintf(&b, "🤖 Auto execute: %s @ %.2f lots\n", strings.Join(sortedKeys(config.AutoExecuteStrategies), ", "), config.AutoExecuteLots)
+	}
+	if signalsPaused.Load() {
+		b.WriteString("⏸️ Signals paused\n")
+	}
+	if reason := tradingBlockedReason(time.Now()); reason != "" {
+		fmt.Fprintf(&b, "⛔ %s\n", reason)
+	}
+
+	if journal != nil {
+		if open, err := journal.OpenTrades(); err == nil {
+			lots := map[string]float64{}
+			for _, t := range open {
+				if strings.EqualFold(t.Side, "SELL") {
+					lots[t.Symbol] -= t.Lots
+				} else {
+					lots[t.Symbol] += t.Lots
+				}
+			}
+			fmt.Fprintf(&b, "📂 Open positions: %d\n", len(open))
+			for _, symbol := range sortedKeys(lots) {
+				fmt.Fprintf(&b, "• %s: %+.2f lots\n", symbol, lots[symbol])
+			}
+		}
+	}
+	sendTelegramTo(chatID, strings.TrimRight(b.String(), "\n"))
+}
+
+func handleStrategiesCommand(from TelegramUser, chatID int64, args []string) {
+	if len(args) >= 2 {
+		action := strings.ToLower(args[0])
+		if action != "enable" && action != "disable" {
+			sendTelegramTo(chatID, "Usage: /strategies [enable|disable NAME]")
+			return
+		}
+		if userRole(from.ID, chatID) < roleAdmin {
+			sendTelegramTo(chatID, "🔒 /strategies "+action+" requires admin role")
+			return
+		}
+		name := strings.ToUpper(args[1])
+		setStrategyEnabled(name, action == "enable")
+		log.Printf("🎯 Strategy %s %sd by user %d", name, action, from.ID)
+		sendTelegramTo(chatID, fmt.Sprintf("🎯 Strategy %s %sd", name, action))
+		return
+	}
+
+	// Strategi yang dikenal: dari journal (30 hari), auto execute, dan yang dimatikan
+	known := map[string]bool{}
+	if names, err := journal.RecentStrategies(time.Now().AddDate(0, 0, -30).Unix()); err == nil {
+		for _, name := range names {
+			known[strings.ToUpper(name)] = true
+		}
+	}
+	for name := range config.AutoExecuteStrategies {
+		known[name] = true
+	}
+	strategiesMu.Lock()
+	for name := range disabledStrategies {
+		known[name] = true
+	}
+	strategiesMu.Unlock()
+
+	if len(known) == 0 {
+		sendTelegramTo(chatID, "🎯 No strategies seen yet")
+		return
+	}
+	var b strings.Builder
+	b.WriteString("🎯 Strategies\n")
+	for _, name := range sortedKeys(known) {
+		state := "✅ enabled"
+		if isStrategyDisabled(name) {
+			state = "🚫 disabled"
+		}
+		if config.AutoExecuteStrategies[name] {
+			state += " 🤖 auto"
+		}
+		fmt.Fprintf(&b, "• %s: %s\n", name, state)
+	}
+	sendTelegramTo(chatID, strings.TrimRight(b.String(), "\n"))
+}
+
+func handleCloseCommand(from TelegramUser, chatID int64, args []string) {
+	if len(args) < 1 {
+		sendTelegramTo(chatID, "Usage: /close <ticket>")
+		return
+	}
+	ticket, err := strconv.Atoi(strings.TrimPrefix(args[0], "#"))
+	if err != nil || ticket <= 0 {
+		sendTelegramTo(chatID, fmt.Sprintf("⚠️ Invalid ticket %q", args[0]))
+		return
+	}
+
+	// Hanya ticket: EA menutup order itu saja, tanpa fallback ke close by symbol/strategi
+	cmd := enqueueClose(ticket, "", "")
+	journal.RecordCommand(cmd, 0, "command", from.ID)
+	sendTelegramTo(chatID, fmt.Sprintf("🔴 Close order #%d queued", ticket))
+}
+
+func handlePauseCommand(from TelegramUser, chatID int64, args []string) {
+	signalsPaused.Store(true)
+	log.Printf("⏸️ Signals paused by user %d", from.ID)
+	sendTelegramTo(chatID, "⏸️ Signals paused — open signals are rejected until /resume")
+}
+
+func handleResumeCommand(from TelegramUser, chatID int64, args []string) {
+	signalsPaused.Store(false)
+	log.Printf("▶️ Signals resumed by user %d", from.ID)
+	sendTelegramTo(chatID, "▶️ Signals resumed")
+}
+
+// formatAccountInfo - ACCOUNT_INFO dari EA: reason = balance;equity;margin;free_margin;currency;leverage
+func formatAccountInfo(p SignalPayload, ts string, lang string) message {
+	parts := strings.Split(p.Reason, ";")
+	for len(parts) < 6 {
+		parts = append(parts, "")
+	}
+	return templateMessage("account_info", messageData{
+		SignalPayload: p, Lang: lang, Time: ts,
+		Balance: parts[0], Equity: parts[1], Margin: parts[2], FreeMargin: parts[3], Currency: parts[4], Leverage: parts[5],
+	})
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============ TRADE CONFIRMATION ============
+// Trade manual di atas CONFIRM_LOTS_ABOVE / CONFIRM_RISK_PCT, atau saat risk limit
+// hampir tercapai, tidak langsung di-enqueue: pesan signal diedit menjadi preview
+// (SL/TP, risiko dalam mata uang akun atau quote currency, R:R) dengan tombol Confirm / Cancel.
+// Cancel mengembalikan pesan dan tombol lot semula.
+
+const (
+	confirmTTL         = 5 * time.Minute
+	riskLimitNearRatio = 0.8 // 80% dari MAX_LOT_SIZE / MAX_PENDING_OPENS
+)
+
+type pendingConfirmation struct {
+	Trade     TradeCommand
+	Text      string                  // teks pesan signal sebelum preview (markup TELEGRAM_PARSE_MODE)
+	PlainText string                  // teks polos dari Telegram, fallback untuk markMessageOutcome
+	Buttons   *TelegramInlineKeyboard // keyboard semula, dipasang lagi saat Cancel
+	SignalID  int64                   // signal di journal, tidak di-expire selama menunggu konfirmasi
+	CreatedAt time.Time
+}
+
+var confirmMu sync.Mutex
+var pendingConfirms = map[string]pendingConfirmation{}
+
+// confirmationReasons - Alasan trade perlu dikonfirmasi (kosong = langsung eksekusi)
+func confirmationReasons(trade TradeCommand, riskPct float64) []string {
+	var reasons []string
+	if config.ConfirmLotsAbove > 0 && trade.Lots > config.ConfirmLotsAbove {
+		reasons = append(reasons, fmt.Sprintf("lot %.2f > %.2f", trade.Lots, config.ConfirmLotsAbove))
+	}
+	if config.ConfirmRiskPct > 0 && riskPct > config.ConfirmRiskPct {
+		reasons = append(reasons, fmt.Sprintf("risk %.2f%% > %.2f%%", riskPct, config.ConfirmRiskPct))
+	}
+	if config.MaxLotSize > 0 && trade.Lots >= config.MaxLotSize*riskLimitNearRatio {
+		reasons = append(reasons, fmt.Sprintf("near MAX_LOT_SIZE %.2f", config.MaxLotSize))
+	}
+	if config.MaxPendingOpens > 0 && float64(countQueuedOpens()+1) >= float64(config.MaxPendingOpens)*riskLimitNearRatio {
+		reasons = append(reasons, fmt.Sprintf("near MAX_PENDING_OPENS %d", config.MaxPendingOpens))
+	}
+	return reasons
+}
+
+// formatTradePreview - Ringkasan trade untuk pesan konfirmasi
+func formatTradePreview(trade TradeCommand, reasons []string) string {
+	risk, reward, rr, currency := tradeRisk(trade)
+
+	var b strings.Builder
+	b.WriteString("⚠️ Confirm trade\n")
+	fmt.Fprintf(&b, "📦 %s %s %.2f lot @ %.2f\n", trade.Side, trade.Symbol, trade.Lots, trade.Price)
+	fmt.Fprintf(&b, "🛑 SL: %.2f | 🎯 TP: %.2f\n", trade.SL, trade.TP)
+	if pct := riskPercent(risk, currency); pct > 0 {
+		fmt.Fprintf(&b, "💸 Risk: %.2f %s (%.2f%% of balance)\n", risk, currency, pct)
+	} else {
+		fmt.Fprintf(&b, "💸 Risk: %.2f %s\n", risk, currency)
+	}
+	fmt.Fprintf(&b, "💰 Reward: %.2f %s | ⚖️ R:R 1:%.2f\n", reward, currency, rr)
+	fmt.Fprintf(&b, "❗ %s", strings.Join(reasons, ", "))
+	return b.String()
+}
+
+// requestTradeConfirmation - true jika trade ditahan untuk konfirmasi (pesan sudah diedit)
+func requestTradeConfirmation(callback *TelegramCallbackQuery, trade TradeCommand) bool {
+	risk, _, _, currency := tradeRisk(trade)
+	reasons := confirmationReasons(trade, riskPercent(risk, currency))
+	if len(reasons) == 0 {
+		return false
+	}
+
+	chatID, messageID := callback.Message.Chat.ID, callback.Message.MessageID
+	original := signalText(chatID, messageID, callback.Message.Text)
+	signalID := journal.SignalIDForMessage(chatID, messageID)
+
+	confirmMu.Lock()
+	for id, c := range pendingConfirms {
+		if time.Since(c.CreatedAt) > confirmTTL {
+			delete(pendingConfirms, id)
+		}
+	}
+	pendingConfirms[trade.ID] = pendingConfirmation{
+		Trade:     trade,
+		Text:      original,
+		PlainText: callback.Message.Text,
+		Buttons:   callback.Message.ReplyMarkup,
+		SignalID:  signalID,
+		CreatedAt: time.Now(),
+	}
+	confirmMu.Unlock()
+
+	buttons := &TelegramInlineKeyboard{
+		InlineKeyboard: [][]TelegramInlineButton{
+			{
+				{Text: "✅ Confirm", CallbackData: "tconfirm|" + trade.ID},
+				{Text: "✖️ Cancel", CallbackData: "tcancel|" + trade.ID},
+			},
+		},
+	}
+	preview := rewriteSignalMessage(original, formatTradePreview(trade, reasons)) // preview di-escape di sini
+
+	answerCallbackQuery(callback.ID, "⚠️ Confirmation required")
+	if err := editSignalMessage(chatID, messageID, preview, buttons); err != nil {
+		log.Printf("⚠️ editSignalMessage error: %v", err)
+	}
+	log.Printf("⚠️ Trade held for confirmation: %s %s %.2f lots (%s)", trade.Symbol, trade.Side, trade.Lots, strings.Join(reasons, ", "))
+	return true
+}
+
+// confirmingSignalIDs - Signal yang pesannya sedang menampilkan Confirm / Cancel
+func confirmingSignalIDs() map[int64]bool {
+	confirmMu.Lock()
+	defer confirmMu.Unlock()
+	ids := map[int64]bool{}
+	for _, c := range pendingConfirms {
+		if c.SignalID != 0 && time.Since(c.CreatedAt) <= confirmTTL {
+			ids[c.SignalID] = true
+		}
+	}
+	return ids
+}
+
+func takePendingConfirmation(id string) (pendingConfirmation, bool) {
+	confirmMu.Lock()
+	defer confirmMu.Unlock()
+	c, ok := pendingConfirms[id]
+	delete(pendingConfirms, id)
+	if ok && time.Since(c.CreatedAt) > confirmTTL {
+		return c, false
+	}
+	return c, ok
+}
+
+// handleTradeConfirmCallback - tconfirm (enqueue) / tcancel (kembalikan pesan signal)
+func handleTradeConfirmCallback(callback *TelegramCallbackQuery, action, id string) {
+	chatID, messageID := callback.Message.Chat.ID, callback.Message.MessageID
+	pending, ok := takePendingConfirmation(id)
+	if !ok {
+		answerCallbackQuery(callback.ID, "⌛ Confirmation expired")
+		if pending.Text != "" {
+			editSignalMessage(chatID, messageID, pending.Text, pending.Buttons)
+		} else {
+			removeInlineKeyboard(chatID, messageID)
+		}
+		return
+	}
+
+	switch action {
+	case "tconfirm":
+		if refuseIfEAOffline(callback) {
+			return
+		}
+		dispatchManualOpen(callback, pending.Trade, pending.PlainText)
+
+	case "tcancel":
+		answerCallbackQuery(callback.ID, "Cancelled")
+		log.Printf("✖️ Trade confirmation cancelled by user %d: %s %s %.2f lots", callback.From.ID, pending.Trade.Symbol, pending.Trade.Side, pending.Trade.Lots)
+		if err := editSignalMessage(chatID, messageID, pending.Text, pending.Buttons); err != nil {
+			log.Printf("⚠️ editSignalMessage error: %v", err)
+		}
+	}
+}
+
+// dispatchManualOpen - Tulis perintah ke MT4, enqueue untuk EA, dan catat hasilnya di pesan signal
+func dispatchManualOpen(callback *TelegramCallbackQuery, trade TradeCommand, signalText string) {
+	if refuseIfTradingBlocked(callback) {
+		return
+	}
+	// Lot dicek dulu; MAX_PENDING_OPENS dan shutdown dicek lagi saat enqueue dalam satu lock
+	err := checkRiskGuards(trade)
+	if err == nil {
+		err = enqueueTradeGuarded(trade)
+	}
+	if err != nil {
+		answerCallbackQuery(callback.ID, "🛡️ "+err.Error())
+		log.Printf("🛡️ Manual trade blocked: %s %s %.2f lots by user %d: %v", trade.Symbol, trade.Side, trade.Lots, callback.From.ID, err)
+		if err != errQueueClosed {
+			publishEvent(eventRiskBreached, riskEvent{Source: "manual", Reason: err.Error(), Trade: trade})
+		}
+		return
+	}
+	if err := sendTradeToMT4(trade); err != nil {
+		answerCallbackQuery(callback.ID, "❌ Trade failed")
+		log.Printf("❌ sendTradeToMT4 error: %v", err)
+	} else {
+		answerCallbackQuery(callback.ID, fmt.Sprintf("✅ %.2f lot sent!", trade.Lots))
+		log.Printf("✅ Trade command dispatched to MT4")
+		markMessageOutcome(callback.Message.Chat.ID, callback.Message.MessageID, signalText,
+			fmt.Sprintf("✅ Executed %.2f lot @ %.2f by %s", trade.Lots, trade.Price, userLabel(callback)))
+	}
+	journalCallback(callback, signalExecuted, trade.Lots, &trade)
+}
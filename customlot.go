@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============ CUSTOM LOT ============
+// Tombol "✏️ Custom" di open signal meminta ukuran lot (mis. 0.35) atau risiko %
+// (mis. 1.5%) lewat ForceReply. State disimpan per user + pesan prompt; balasan
+// divalidasi terhadap lot step / min / max symbol lalu diproses seperti callback lot
+// (termasuk konfirmasi trade besar).
+
+const customLotTTL = 5 * time.Minute
+
+// replyPromptKey - Prompt ForceReply milik satu user (juga dipakai /settings)
+type replyPromptKey struct {
+	UserID   int64
+	PromptID int
+}
+
+type customLotPrompt struct {
+	Symbol    string
+	Side      string
+	Price     float64
+	Strategy  string
+	ATR       float64
+	Signal    TelegramCallbackQuery // pesan signal asal (chat, message, teks, keyboard)
+	CreatedAt time.Time
+}
+
+var customLotMu sync.Mutex
+var customLotPrompts = map[replyPromptKey]customLotPrompt{}
+
+// symbolLotLimits - LOT_STEP / MIN_LOT / MAX_LOT, bisa per symbol (mis. LOT_STEP_XAUUSD)
+func symbolLotLimits(symbol string) (step, min, max float64) {
+	step = getEnvFloat("LOT_STEP_"+symbol, config.LotStep)
+	min = getEnvFloat("MIN_LOT_"+symbol, config.MinLot)
+	max = getEnvFloat("MAX_LOT_"+symbol, config.MaxLot)
+	if config.MaxLotSize > 0 && config.MaxLotSize < max {
+		max = config.MaxLotSize
+	}
+	return step, min, max
+}
+
+// validateLotSteps - LOT_STEP dan semua LOT_STEP_<SYMBOL> harus angka > 0 (step 0 membuat lot NaN)
+func validateLotSteps() error {
+	for _, env := range os.Environ() {
+		key, val, _ := strings.Cut(env, "=")
+		if key != "LOT_STEP" && !strings.HasPrefix(key, "LOT_STEP_") {
+			continue
+		}
+		if step, err := strconv.ParseFloat(strings.TrimSpace(val), 64); err != nil || step <= 0 {
+			return fmt.Errorf("%s must be a number > 0, got %q", key, val)
+		}
+	}
+	return nil
+}
+
+// parseCustomLot - "0.35" = lot, "1.5%" = risiko % balance (dibulatkan ke bawah sesuai lot step)
+func parseCustomLot(input string, prompt customLotPrompt) (float64, error) {
+	input = strings.TrimSpace(strings.ReplaceAll(input, ",", "."))
+	step, min, max := symbolLotLimits(prompt.Symbol)
+
+	var lots float64
+	if strings.HasSuffix(input, "%") {
+		pct, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(input, "%")), 64)
+		if err != nil || pct <= 0 {
+			return 0, fmt.Errorf("invalid risk %q", input)
+		}
+		balance, accCurrency := currentBalance()
+		if balance <= 0 {
+			return 0, fmt.Errorf("balance unknown — run /balance or set ACCOUNT_BALANCE")
+		}
+		perLot, _, _, currency := tradeRisk(buildOpenTrade(prompt.Symbol, prompt.Side, prompt.Price, prompt.Strategy, 1, prompt.ATR))
+		if perLot <= 0 {
+			return 0, fmt.Errorf("cannot compute risk per lot for %s", prompt.Symbol)
+		}
+		if currency != accCurrency {
+			return 0, fmt.Errorf("risk for %s is in %s, cannot convert to %s — enter a lot size", prompt.Symbol, currency, accCurrency)
+		}
+		lots = math.Floor(balance*pct/100/perLot/step+1e-9) * step
+	} else {
+		v, err := strconv.ParseFloat(input, 64)
+		if err != nil || v <= 0 {
+			return 0, fmt.Errorf("invalid lot size %q", input)
+		}
+		if steps := v / step; math.Abs(steps-math.Round(steps)) > 1e-6 {
+			return 0, fmt.Errorf("lot %g is not a multiple of lot step %g", v, step)
+		}
+		lots = v
+	}
+
+	lots = math.Round(lots/step) * step
+	if lots < min {
+		return 0, fmt.Errorf("lot %.2f below minimum %g for %s", lots, min, prompt.Symbol)
+	}
+	if lots > max {
+		return 0, fmt.Errorf("lot %.2f above maximum %g for %s", lots, max, prompt.Symbol)
+	}
+	return lots, nil
+}
+
+// sendForceReply - Kirim prompt yang membuka kolom balasan di Telegram
+func sendForceReply(chatID int64, text, placeholder string) (*TelegramSentMessage, error) {
+	payload := map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+		"reply_markup": map[string]interface{}{
+			"force_reply":             true,
+			"selective":               true,
+			"input_field_placeholder": placeholder,
+		},
+	}
+	b, _ := json.Marshal(payload)
+	resp, err := telegramPost("sendMessage", "application/json", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, parseTelegramError(resp.StatusCode, resp.Body)
+	}
+	var result struct {
+		Result TelegramSentMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result.Result, nil
+}
+
+// promptCustomLot - Kirim ForceReply dan simpan state; prompt lama user untuk signal yang sama diganti
+func promptCustomLot(user TelegramUser, prompt customLotPrompt, note string) {
+	step, min, max := symbolLotLimits(prompt.Symbol)
+	mention := fmt.Sprintf("user %d", user.ID)
+	if user.Username != "" {
+		mention = "@" + user.Username
+	}
+	// Contoh di prompt memakai preset user yang menekan (keyboard grup memakai default)
+	prefs := getUserPrefs(user.ID)
+	example := "0.35 or 1.5%"
+	if len(prefs.LotPresets) > 0 {
+		example = strconv.FormatFloat(prefs.LotPresets[0], 'f', -1, 64)
+		if prefs.RiskPct > 0 {
+			example += " or " + strconv.FormatFloat(prefs.RiskPct, 'f', -1, 64) + "%"
+		}
+	}
+	text := fmt.Sprintf("✏️ %s, reply with a lot size or risk %% for %s %s @ %.2f\n📏 Step %g | Min %g | Max %g\n💡 e.g. %s — reply \"cancel\" to abort",
+		mention, prompt.Side, prompt.Symbol, prompt.Price, step, min, max, example)
+	if len(prefs.LotPresets) > 1 {
+		text += "\n📊 Your presets: " + formatLotList(prefs.LotPresets)
+	}
+	if note != "" {
+		text = note + "\n" + text
+	}
+
+	sent, err := sendForceReply(prompt.Signal.Message.Chat.ID, text, example)
+	if err != nil {
+		log.Printf("⚠️ sendForceReply error: %v", err)
+		return
+	}
+
+	prompt.CreatedAt = time.Now()
+	customLotMu.Lock()
+	for key, p := range customLotPrompts {
+		expired := time.Since(p.CreatedAt) > customLotTTL
+		sameSignal := key.UserID == user.ID && p.Signal.Message.MessageID == prompt.Signal.Message.MessageID
+		if expired || sameSignal {
+			delete(customLotPrompts, key)
+		}
+	}
+	customLotPrompts[replyPromptKey{UserID: user.ID, PromptID: sent.MessageID}] = prompt
+	customLotMu.Unlock()
+}
+
+// handleCustomLotCallback - Tombol ✏️ Custom: custom|symbol|side|price|strategy|atr
+func handleCustomLotCallback(callback *TelegramCallbackQuery, parts []string) {
+	if len(parts) < 5 {
+		return
+	}
+	price, _ := strconv.ParseFloat(parts[3], 64)
+	prompt := customLotPrompt{Symbol: parts[1], Side: parts[2], Price: price, Strategy: parts[4], Signal: *callback}
+	if len(parts) >= 6 {
+		prompt.ATR, _ = strconv.ParseFloat(parts[5], 64)
+	}
+	prompt.Signal.ID = ""
+
+	answerCallbackQuery(callback.ID, "✏️ Reply with lot size or risk %")
+	promptCustomLot(TelegramUser{ID: callback.From.ID, Username: callback.From.Username}, prompt, "")
+}
+
+// handleCustomLotReply - true jika pesan adalah balasan ke prompt custom lot
+func handleCustomLotReply(msg *TelegramIncomingMessage) bool {
+	if msg.ReplyToMessage == nil {
+		return false
+	}
+	key := replyPromptKey{UserID: msg.From.ID, PromptID: msg.ReplyToMessage.MessageID}
+
+	customLotMu.Lock()
+	prompt, ok := customLotPrompts[key]
+	delete(customLotPrompts, key)
+	customLotMu.Unlock()
+	if !ok {
+		return false
+	}
+
+	if time.Since(prompt.CreatedAt) > customLotTTL {
+		sendTelegramTo(msg.Chat.ID, "⌛ Custom lot prompt expired — tap ✏️ Custom again")
+		return true
+	}
+	if strings.EqualFold(strings.TrimSpace(msg.Text), "cancel") {
+		sendTelegramTo(msg.Chat.ID, "✖️ Custom lot cancelled")
+		return true
+	}
+	if userRole(msg.From.ID, msg.Chat.ID) < roleTrader {
+		sendTelegramTo(msg.Chat.ID, "🔒 Trader role required")
+		return true
+	}
+	if config.EAOfflineAction == "refuse" {
+		if reason := signalOfflineReason(prompt.Signal.Message.Chat.ID, prompt.Signal.Message.MessageID); reason != "" {
+			sendTelegramTo(msg.Chat.ID, "📴 "+reason)
+			return true
+		}
+	}
+
+	if reason := manualTradingBlockedReason(time.Now()); reason != "" {
+		sendTelegramTo(msg.Chat.ID, "⛔ "+reason)
+		return true
+	}
+
+	prompt.ATR = getUserPrefs(msg.From.ID).tradeATR(prompt.ATR)
+	lots, err := parseCustomLot(msg.Text, prompt)
+	if err != nil {
+		log.Printf("✏️ Custom lot rejected for user %d: %v", msg.From.ID, err)
+		promptCustomLot(msg.From, prompt, "❌ "+err.Error())
+		return true
+	}
+
+	trade := buildOpenTrade(prompt.Symbol, prompt.Side, prompt.Price, prompt.Strategy, lots, prompt.ATR)
+	trade.ID = newCommandID()
+	log.Printf("🟢 TRADE request (custom lot %q): %s %s price=%.2f lots=%.2f sl=%.2f tp=%.2f strat=%s", msg.Text, trade.Symbol, trade.Side, trade.Price, lots, trade.SL, trade.TP, trade.Strategy)
+
+	// Lanjut seperti callback lot, memakai pesan signal asal
+	callback := prompt.Signal
+	callback.From.ID, callback.From.Username = msg.From.ID, msg.From.Username
+	if requestTradeConfirmation(&callback, trade) {
+		return true
+	}
+	dispatchManualOpen(&callback, trade, callback.Message.Text)
+	return true
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseCustomLot(t *testing.T) {
+	t.Setenv("LOT_STEP_XAUUSD", "0.1")
+	accountMu.Lock()
+	oldBalance, oldCurrency := accountBalance, accountCurrency
+	accountBalance, accountCurrency = 0, "USD"
+	accountMu.Unlock()
+	t.Cleanup(func() {
+		accountMu.Lock()
+		accountBalance, accountCurrency = oldBalance, oldCurrency
+		accountMu.Unlock()
+	})
+
+	base := Config{LotStep: 0.01, MinLot: 0.01, MaxLot: 5, ForexContractSize: 100000, GoldContractSize: 100, AccountBalance: 10000}
+	tests := []struct {
+		name       string
+		input      string
+		symbol     string
+		balance    float64 // ACCOUNT_BALANCE; -1 = tidak diketahui
+		maxLotSize float64
+		want       float64
+		wantErr    string
+	}{
+		{name: "lot", input: "0.35", symbol: "EURUSD", want: 0.35},
+		{name: "decimal comma", input: " 0,5 ", symbol: "EURUSD", want: 0.5},
+		// SL fixed 50 pip EURUSD = 500 USD per lot
+		{name: "risk percent", input: "1.5%", symbol: "EURUSD", want: 0.3},
+		{name: "risk rounds down to step", input: "0.7 %", symbol: "EURUSD", want: 0.14},
+		{name: "risk below minimum", input: "0.001%", symbol: "EURUSD", wantErr: "below minimum"},
+		{name: "not a step multiple", input: "0.005", symbol: "EURUSD", wantErr: "not a multiple of lot step 0.01"},
+		{name: "per-symbol step", input: "0.2", symbol: "XAUUSD", want: 0.2},
+		{name: "per-symbol step rejects", input: "0.15", symbol: "XAUUSD", wantErr: "lot step 0.1"},
+		{name: "above MAX_LOT", input: "6", symbol: "EURUSD", wantErr: "above maximum 5"},
+		{name: "MAX_LOT_SIZE caps", input: "3", symbol: "EURUSD", maxLotSize: 2, wantErr: "above maximum 2"},
+		{name: "zero", input: "0", symbol: "EURUSD", wantErr: "invalid lot size"},
+		{name: "text", input: "half", symbol: "EURUSD", wantErr: "invalid lot size"},
+		{name: "negative risk", input: "-1%", symbol: "EURUSD", wantErr: "invalid risk"},
+		{name: "balance unknown", input: "1%", symbol: "EURUSD", balance: -1, wantErr: "balance unknown"},
+		{name: "cross pair", input: "1%", symbol: "EURGBP", wantErr: "risk for EURGBP is in GBP"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			cfg.MaxLotSize = tt.maxLotSize
+			if tt.balance < 0 {
+				cfg.AccountBalance = 0
+			}
+			testConfig(t, cfg)
+
+			got, err := parseCustomLot(tt.input, customLotPrompt{Symbol: tt.symbol, Side: "BUY", Price: 1.1, Strategy: "TEST"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseCustomLot(%q) = %v, %v; want error %q", tt.input, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCustomLot(%q): %v", tt.input, err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("parseCustomLot(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
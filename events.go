@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============ OUTBOUND EVENT WEBHOOKS ============
+// Sistem lain (risk sheet, aplikasi jurnal) bisa menerima event JSON lewat
+// EVENT_WEBHOOKS, aturan dipisah ";":
+//
+//	<url> [events=order.opened,order.closed]
+//
+// Body ditandatangani HMAC-SHA256 dengan EVENT_WEBHOOK_SECRET atas "<timestamp>.<body>"
+// (header X-Event-Signature: sha256=<hex>, X-Event-Timestamp). Setiap URL punya worker
+// sendiri (urutan event terjaga); gagal setelah EVENT_WEBHOOK_MAX_RETRIES masuk ke
+// dead-letter log (JSON lines di EVENT_DEADLETTER_PATH) dan bisa dikirim ulang lewat
+// POST /events/replay.
+
+const (
+	eventSignalReceived  = "signal.received"
+	eventCommandEnqueued = "command.enqueued"
+	eventOrderOpened     = "order.opened"
+	eventOrderClosed     = "order.closed"
+	eventRiskBreached    = "risk.breached"
+
+	eventQueueSize   = 500
+	eventBaseBackoff = 2 * time.Second
+	eventMaxBackoff  = 60 * time.Second
+)
+
+var eventTypes = []string{eventSignalReceived, eventCommandEnqueued, eventOrderOpened, eventOrderClosed, eventRiskBreached}
+
+type event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type eventEndpoint struct {
+	URL    string
+	Events map[string]bool // kosong = semua event
+	queue  chan event
+}
+
+// Isi field "data" per jenis event (command.enqueued memakai TradeCommand)
+type signalEvent struct {
+	SignalID int64         `json:"signal_id,omitempty"`
+	Status   string        `json:"status"`
+	Note     string        `json:"note,omitempty"`
+	Signal   SignalPayload `json:"signal"`
+}
+
+type orderEvent struct {
+	SignalID  int64   `json:"signal_id,omitempty"`
+	Ticket    int64   `json:"ticket"`
+	Account   string  `json:"account,omitempty"`
+	Symbol    string  `json:"symbol"`
+	Side      string  `json:"side"`
+	Strategy  string  `json:"strategy,omitempty"`
+	Lots      float64 `json:"lots"`
+	Price     float64 `json:"price"`
+	OpenPrice float64 `json:"open_price,omitempty"`
+	Profit    float64 `json:"profit,omitempty"`
+	Currency  string  `json:"currency,omitempty"`
+}
+
+type riskEvent struct {
+	Source string       `json:"source"`
+	Reason string       `json:"reason"`
+	Trade  TradeCommand `json:"trade"`
+}
+
+// deadLetter - Satu baris di dead-letter log
+type deadLetter struct {
+	URL      string    `json:"url"`
+	Event    event     `json:"event"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+var (
+	eventEndpoints []*eventEndpoint
+	deadLetterMu   sync.Mutex
+)
+
+// eventClient - Timeout per request, supaya endpoint yang menggantung tidak memblokir worker
+var eventClient = &http.Client{Timeout: 15 * time.Second}
+
+// parseEventWebhooks - Parse EVENT_WEBHOOKS
+func parseEventWebhooks(spec string) ([]*eventEndpoint, error) {
+	var endpoints []*eventEndpoint
+	for _, rule := range strings.Split(spec, ";") {
+		fields := strings.Fields(rule)
+		if len(fields) == 0 {
+			continue
+		}
+		if !strings.HasPrefix(fields[0], "http://") && !strings.HasPrefix(fields[0], "https://") {
+			return nil, fmt.Errorf("webhook %q: url must start with http:// or https://", strings.TrimSpace(rule))
+		}
+		ep := &eventEndpoint{URL: fields[0], Events: map[string]bool{}, queue: make(chan event, eventQueueSize)}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok || strings.ToLower(key) != "events" {
+				return nil, fmt.Errorf("webhook %q: expected events=..., got %q", strings.TrimSpace(rule), field)
+			}
+			for _, t := range strings.Split(strings.ToLower(value), ",") {
+				if !containsString(eventTypes, t) {
+					return nil, fmt.Errorf("webhook %q: unknown event %q (%s)", strings.TrimSpace(rule), t, strings.Join(eventTypes, ", "))
+				}
+				ep.Events[t] = true
+			}
+		}
+		endpoints = append(endpoints, ep)
+	}
+	return endpoints, nil
+}
+
+// startEventWebhooks - Satu worker per URL
+func startEventWebhooks() {
+	if len(eventEndpoints) == 0 {
+		return
+	}
+	if config.EventWebhookSecret == "" {
+		log.Printf("⚠️  EVENT_WEBHOOK_SECRET empty: outbound events are not signed")
+	}
+	for _, ep := range eventEndpoints {
+		log.Printf("🔗 Event webhook: %s (%s)", ep.URL, getOr(strings.Join(sortedKeys(ep.Events), ","), "all events"))
+		go ep.run()
+	}
+}
+
+func newEventID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "evt_" + hex.EncodeToString(b)
+}
+
+// publishEvent - Kirim event ke semua webhook yang berlangganan (non-blocking)
+func publishEvent(eventType string, data interface{}) {
+	if len(eventEndpoints) == 0 {
+		return
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("❌ event %s marshal error: %v", eventType, err)
+		return
+	}
+	ev := event{ID: newEventID(), Type: eventType, CreatedAt: time.Now().UTC(), Data: raw}
+
+	for _, ep := range eventEndpoints {
+		if len(ep.Events) > 0 && !ep.Events[eventType] {
+			continue
+		}
+		select {
+		case ep.queue <- ev:
+		default:
+			writeDeadLetter(deadLetter{URL: ep.URL, Event: ev, Error: "queue full", FailedAt: time.Now().UTC()})
+		}
+	}
+}
+
+func (ep *eventEndpoint) run() {
+	for ev := range ep.queue {
+		attempts, err := ep.deliver(ev)
+		if err != nil {
+			log.Printf("❌ Event %s %s → %s failed after %d attempts: %v (dead-lettered)", ev.Type, ev.ID, ep.URL, attempts, err)
+			metricEventFailures.Inc(ev.Type)
+			writeDeadLetter(deadLetter{URL: ep.URL, Event: ev, Error: err.Error(), Attempts: attempts, FailedAt: time.Now().UTC()})
+		}
+	}
+}
+
+// signEvent - hex HMAC-SHA256(secret, "<timestamp>.<body>")
+func signEvent(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(config.EventWebhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver - POST dengan retry (network error, 429, 5xx); 4xx lain langsung gagal
+func (ep *eventEndpoint) deliver(ev event) (int, error) {
+	body, _ := json.Marshal(ev)
+	backoff := eventBaseBackoff
+	for attempt := 1; ; attempt++ {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req, _ := http.NewRequest(http.MethodPost, ep.URL, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Event-ID", ev.ID)
+		req.Header.Set("X-Event-Type", ev.Type)
+		req.Header.Set("X-Event-Timestamp", timestamp)
+		if config.EventWebhookSecret != "" {
+			req.Header.Set("X-Event-Signature", "sha256="+signEvent(timestamp, body))
+		}
+
+		resp, err := eventClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				return attempt, nil
+			}
+			err = fmt.Errorf("%s", resp.Status)
+			if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+				return attempt, err
+			}
+		}
+		if attempt > config.EventMaxRetries {
+			return attempt, err
+		}
+		log.Printf("⏳ Event %s → %s failed (attempt %d): %v — retry in %s", ev.Type, ep.URL, attempt, err, backoff)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > eventMaxBackoff {
+			backoff = eventMaxBackoff
+		}
+	}
+}
+
+// ============ DEAD LETTERS ============
+
+func writeDeadLetter(d deadLetter) {
+	deadLetterMu.Lock()
+	defer deadLetterMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(config.EventDeadLetterPath), 0755); err != nil {
+		log.Printf("❌ dead-letter dir error: %v", err)
+		return
+	}
+	f, err := os.OpenFile(config.EventDeadLetterPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("❌ dead-letter write error: %v", err)
+		return
+	}
+	defer f.Close()
+	line, _ := json.Marshal(d)
+	f.Write(append(line, '\n'))
+}
+
+// readDeadLetters - Isi dead-letter log (file belum ada = kosong). Pemanggil memegang deadLetterMu.
+func readDeadLetters() ([]deadLetter, error) {
+	f, err := os.Open(config.EventDeadLetterPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var letters []deadLetter
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var d deadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &d); err != nil {
+			log.Printf("⚠️ dead-letter line skipped: %v", err)
+			continue
+		}
+		letters = append(letters, d)
+	}
+	return letters, scanner.Err()
+}
+
+// writeDeadLetters - Tulis ulang dead-letter log (atomic rename). Pemanggil memegang deadLetterMu.
+func writeDeadLetters(letters []deadLetter) error {
+	var buf bytes.Buffer
+	for _, d := range letters {
+		line, _ := json.Marshal(d)
+		buf.Write(append(line, '\n'))
+	}
+	tmp := config.EventDeadLetterPath + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, config.EventDeadLetterPath)
+}
+
+// replayDeadLetters - Masukkan lagi dead letter (semua, atau ID tertentu) ke antrian webhook-nya.
+// Yang URL-nya tidak lagi ada di EVENT_WEBHOOKS tetap di log.
+func replayDeadLetters(id string) (int, error) {
+	deadLetterMu.Lock()
+	defer deadLetterMu.Unlock()
+
+	letters, err := readDeadLetters()
+	if err != nil {
+		return 0, err
+	}
+	var kept []deadLetter
+	replayed := 0
+	for _, d := range letters {
+		var target *eventEndpoint
+		for _, ep := range eventEndpoints {
+			if ep.URL == d.URL {
+				target = ep
+				break
+			}
+		}
+		if (id != "" && d.Event.ID != id) || target == nil {
+			kept = append(kept, d)
+			continue
+		}
+		select {
+		case target.queue <- d.Event:
+			replayed++
+		default:
+			kept = append(kept, d)
+		}
+	}
+	if replayed > 0 {
+		if err := writeDeadLetters(kept); err != nil {
+			return replayed, err
+		}
+	}
+	return replayed, nil
+}
+
+// ============ HTTP: /events ============
+
+// deadLettersHandler - GET /events/dead-letters: isi dead-letter log
+func deadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !authorizeAPIRequest(w, r) {
+		return
+	}
+
+	deadLetterMu.Lock()
+	letters, err := readDeadLetters()
+	deadLetterMu.Unlock()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if letters == nil {
+		letters = []deadLetter{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(letters)
+}
+
+// eventsReplayHandler - POST /events/replay[?id=evt_x]: kirim ulang dead letter
+func eventsReplayHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if !authorizeAPIRequest(w, r) {
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	n, err := replayDeadLetters(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	log.Printf("🔁 Replayed %d dead-lettered events (id=%q)", n, id)
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprintf(w, `{"ok":true,"replayed":%d}`, n)
+}
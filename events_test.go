@@ -0,0 +1,109 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseEventWebhooks(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []string // "url events" (events urut, kosong = semua)
+		wantErr string
+	}{
+		{name: "empty", spec: " ; "},
+		{name: "all events", spec: "https://hooks.example.com/a", want: []string{"https://hooks.example.com/a "}},
+		{
+			name: "filtered and several",
+			spec: "https://a.example.com/x events=Order.Closed,order.opened ; http://b.example.com events=risk.breached events=signal.received",
+			want: []string{
+				"https://a.example.com/x order.closed,order.opened",
+				"http://b.example.com risk.breached,signal.received",
+			},
+		},
+		{name: "no scheme", spec: "hooks.example.com/a", wantErr: "url must start with http:// or https://"},
+		{name: "filter first", spec: "events=order.opened https://a.example.com", wantErr: "url must start with"},
+		{name: "unknown key", spec: "https://a.example.com types=order.opened", wantErr: "expected events=..."},
+		{name: "bare word", spec: "https://a.example.com order.opened", wantErr: "expected events=..."},
+		{name: "unknown event", spec: "https://a.example.com events=order.filled", wantErr: `unknown event "order.filled"`},
+		{name: "error names rule", spec: "https://a.example.com; https://b.example.com events=x", wantErr: `webhook "https://b.example.com events=x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints, err := parseEventWebhooks(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseEventWebhooks: %v", err)
+			}
+			var got []string
+			for _, ep := range endpoints {
+				got = append(got, ep.URL+" "+strings.Join(sortedKeys(ep.Events), ","))
+				if ep.queue == nil {
+					t.Errorf("%s: queue not created", ep.URL)
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("endpoints\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestSignEvent(t *testing.T) {
+	// Nilai acuan: HMAC-SHA256(secret, "<timestamp>.<body>") dihitung di luar Go
+	tests := []struct {
+		secret, timestamp, body, want string
+	}{
+		{"s3cret", "1700000000", `{"id":"evt_1"}`, "8e6f5c9e12ccd802130a0cf337c2d90395d59605481627c32bb6c530172adf23"},
+		{"s3cret", "1700000001", `{"id":"evt_1"}`, "7c309b409a4f2f7b9a5b858ff4ee389f856b18f0f406a2e5aef600eb019e4b89"},
+		{"other", "1700000000", `{"id":"evt_1"}`, "e12ef238930e9a9dcbebaf3147df8d7a19ab1524ac7be39f4f8d50cb628f0ab5"},
+		{"s3cret", "1700000000", "", "21948100f1d7a89f3338f6b1106fc4f7a702fbe1493b833a3382f80193bde3fe"},
+	}
+	for _, tt := range tests {
+		testConfig(t, Config{EventWebhookSecret: tt.secret})
+		if got := signEvent(tt.timestamp, []byte(tt.body)); got != tt.want {
+			t.Errorf("signEvent(%s, %s, %s) = %s, want %s", tt.secret, tt.timestamp, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestEventDeliverSignsBody(t *testing.T) {
+	for _, secret := range []string{"s3cret", ""} {
+		testConfig(t, Config{EventWebhookSecret: secret})
+		var header http.Header
+		var body []byte
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header = r.Header.Clone()
+			body, _ = io.ReadAll(r.Body)
+		}))
+
+		ep := &eventEndpoint{URL: srv.URL}
+		if _, err := ep.deliver(event{ID: "evt_1", Type: eventOrderOpened, Data: []byte(`{"ticket":1}`)}); err != nil {
+			t.Fatalf("deliver: %v", err)
+		}
+		srv.Close()
+
+		if header.Get("X-Event-ID") != "evt_1" || header.Get("X-Event-Type") != eventOrderOpened {
+			t.Errorf("event headers = %v", header)
+		}
+		sig := header.Get("X-Event-Signature")
+		if secret == "" {
+			if sig != "" {
+				t.Errorf("unsigned delivery has signature %q", sig)
+			}
+			continue
+		}
+		if want := "sha256=" + signEvent(header.Get("X-Event-Timestamp"), body); sig != want {
+			t.Errorf("signature = %q, want %q", sig, want)
+		}
+	}
+}
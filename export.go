@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============ EXPORT ============
+// Export data journal (signals, commands, trades) sebagai CSV atau JSON,
+// lewat GET /export dan command Telegram /export (dikirim via sendDocument).
+
+type exportFilter struct {
+	Dataset  string // signals | commands | trades
+	Format   string // csv | json
+	From     time.Time
+	To       time.Time
+	Strategy string
+	Symbol   string
+	Account  string
+}
+
+// isoTime - Unix timestamp → RFC3339 UTC di SQLite
+func isoTime(column string) string {
+	return fmt.Sprintf("strftime('%%Y-%%m-%%dT%%H:%%M:%%SZ', %s, 'unixepoch')", column)
+}
+
+var exportQueries = map[string]struct {
+	query      string
+	timeColumn string
+	strategy   string
+	symbol     string
+	account    string
+}{
+	"signals": {
+		query: `SELECT s.id, ` + isoTime("s.received_at") + ` AS received_at, s.account, s.symbol, s.side, s.strategy,
+		        s.timeframe, s.price, s.atr, s.ticket, s.status, s.note, s.decided_by, ` + isoTime("s.decided_at") + ` AS decided_at, s.lots
+		        FROM signals s`,
+		timeColumn: "s.received_at", strategy: "s.strategy", symbol: "s.symbol", account: "s.account",
+	},
+	"commands": {
+		query: `SELECT c.id, c.signal_id, ` + isoTime("c.created_at") + ` AS created_at, ` + isoTime("c.delivered_at") + ` AS delivered_at,
+		        s.account, c.action, c.symbol, c.side, c.lots, c.price, c.sl, c.tp, c.strategy, c.ticket, c.source, c.user_id, c.status
+		        FROM commands c LEFT JOIN signals s ON s.id = c.signal_id`,
+		timeColumn: "c.created_at", strategy: "c.strategy", symbol: "c.symbol", account: "s.account",
+	},
+	"trades": {
+		query: `SELECT t.ticket, t.signal_id, t.command_id, t.account, t.symbol, t.side, t.strategy, t.lots,
+		        t.open_price, ` + isoTime("t.opened_at") + ` AS opened_at, t.close_price, ` + isoTime("t.closed_at") + ` AS closed_at,
+		        t.profit, t.currency
+		        FROM trades t`,
+		timeColumn: "t.closed_at", strategy: "t.strategy", symbol: "t.symbol", account: "t.account",
+	},
+}
+
+// ExportRows - Kolom + baris untuk dataset sesuai filter
+func (j *Journal) ExportRows(f exportFilter) ([]string, [][]interface{}, error) {
+	q, ok := exportQueries[f.Dataset]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown dataset %q (signals, commands, trades)", f.Dataset)
+	}
+
+	where := []string{q.timeColumn + " >= ?", q.timeColumn + " < ?"}
+	args := []interface{}{f.From.Unix(), f.To.Unix()}
+	if f.Strategy != "" {
+		where = append(where, "UPPER("+q.strategy+") = UPPER(?)")
+		args = append(args, f.Strategy)
+	}
+	if f.Symbol != "" {
+		// Prefix match agar suffix broker (XAUUSD.m) ikut
+		where = append(where, "UPPER("+q.symbol+") LIKE UPPER(?) || '%'")
+		args = append(args, f.Symbol)
+	}
+	if f.Account != "" {
+		where = append(where, q.account+" = ?")
+		args = append(args, f.Account)
+	}
+
+	rows, err := j.db.Query(q.query+" WHERE "+strings.Join(where, " AND ")+" ORDER BY "+q.timeColumn, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var data [][]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		data = append(data, values)
+	}
+	return columns, data, rows.Err()
+}
+
+// renderExport - Encode hasil export; kembalikan isi file dan content type
+func renderExport(format string, columns []string, data [][]interface{}) ([]byte, string, error) {
+	var buf bytes.Buffer
+
+	if format == "json" {
+		records := make([]map[string]interface{}, 0, len(data))
+		for _, row := range data {
+			record := make(map[string]interface{}, len(columns))
+			for i, column := range columns {
+				record[column] = row[i]
+			}
+			records = append(records, record)
+		}
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(records); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "application/json", nil
+	}
+
+	w := csv.NewWriter(&buf)
+	w.Write(columns)
+	for _, row := range data {
+		record := make([]string, len(row))
+		for i, v := range row {
+			switch val := v.(type) {
+			case nil:
+				record[i] = ""
+			case float64:
+				record[i] = strconv.FormatFloat(val, 'f', -1, 64)
+			default:
+				record[i] = fmt.Sprint(val)
+			}
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	return buf.Bytes(), "text/csv", w.Error()
+}
+
+func exportFilename(f exportFilter) string {
+	return fmt.Sprintf("%s_%s_%s.%s", f.Dataset, f.From.Format("20060102"), f.To.AddDate(0, 0, -1).Format("20060102"), f.Format)
+}
+
+// parseExportDate - Tanggal YYYY-MM-DD dalam reportLocation()
+func parseExportDate(s string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", s, reportLocation())
+}
+
+// ============ TELEGRAM: /export ============
+// /export [signals|commands|trades] [csv|json] [from YYYY-MM-DD] [to YYYY-MM-DD] [strategy=X] [symbol=X] [account=X]
+// Default: trades csv, 30 hari terakhir. Tanggal "to" inklusif.
+func handleExportCommand(chatID int64, args []string) {
+	if journal == nil {
+		sendTelegramTo(chatID, "⚠️ Journal disabled, export unavailable")
+		return
+	}
+
+	today := time.Now().In(reportLocation())
+	end := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location()).AddDate(0, 0, 1)
+	f := exportFilter{Dataset: "trades", Format: "csv", From: end.AddDate(0, 0, -30), To: end}
+
+	var dates []time.Time
+	for _, arg := range args {
+		lower := strings.ToLower(arg)
+		switch {
+		case lower == "signals" || lower == "commands" || lower == "trades":
+			f.Dataset = lower
+		case lower == "csv" || lower == "json":
+			f.Format = lower
+		case strings.HasPrefix(lower, "strategy="):
+			f.Strategy = arg[len("strategy="):]
+		case strings.HasPrefix(lower, "symbol="):
+			f.Symbol = arg[len("symbol="):]
+		case strings.HasPrefix(lower, "account="):
+			f.Account = arg[len("account="):]
+		default:
+			d, err := parseExportDate(arg)
+			if err != nil {
+				sendTelegramTo(chatID, fmt.Sprintf("⚠️ Unknown export argument %q\nUsage: /export [signals|commands|trades] [csv|json] [YYYY-MM-DD] [YYYY-MM-DD] [strategy=X] [symbol=X] [account=X]", arg))
+				return
+			}
+			dates = append(dates, d)
+		}
+	}
+	if len(dates) >= 1 {
+		f.From = dates[0]
+		f.To = dates[0].AddDate(0, 0, 1)
+	}
+	if len(dates) >= 2 {
+		f.To = dates[1].AddDate(0, 0, 1)
+	}
+
+	columns, data, err := journal.ExportRows(f)
+	if err != nil {
+		log.Printf("❌ export error: %v", err)
+		sendTelegramTo(chatID, "❌ Export failed: "+err.Error())
+		return
+	}
+	content, _, err := renderExport(f.Format, columns, data)
+	if err != nil {
+		log.Printf("❌ export render error: %v", err)
+		sendTelegramTo(chatID, "❌ Export failed: "+err.Error())
+		return
+	}
+
+	caption := fmt.Sprintf("📤 %s export: %d rows", f.Dataset, len(data))
+	if err := sendTelegramFile(strconv.FormatInt(chatID, 10), "sendDocument", "document", exportFilename(f), content, caption); err != nil {
+		log.Printf("❌ sendDocument error: %v", err)
+		return
+	}
+	log.Printf("📤 Export sent: %s %s %d rows", f.Dataset, f.Format, len(data))
+}
+
+// ============ HTTP: EXPORT ============
+// GET /export?token=...&type=trades&format=csv&from=YYYY-MM-DD&to=YYYY-MM-DD&strategy=&symbol=&account=
+func exportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !authorizeAPIRequest(w, r) {
+		return
+	}
+	query := r.URL.Query()
+
+	if journal == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("journal disabled"))
+		return
+	}
+
+	now := time.Now()
+	f := exportFilter{
+		Dataset:  getOr(query.Get("type"), "trades"),
+		Format:   getOr(query.Get("format"), "csv"),
+		From:     now.AddDate(0, 0, -30),
+		To:       now,
+		Strategy: query.Get("strategy"),
+		Symbol:   query.Get("symbol"),
+		Account:  query.Get("account"),
+	}
+	if f.Format != "csv" && f.Format != "json" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("format must be csv or json"))
+		return
+	}
+	if from := query.Get("from"); from != "" {
+		d, err := parseExportDate(from)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid from date"))
+			return
+		}
+		f.From = d
+	}
+	if to := query.Get("to"); to != "" {
+		d, err := parseExportDate(to)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid to date"))
+			return
+		}
+		f.To = d.AddDate(0, 0, 1) // inklusif
+	}
+
+	columns, data, err := journal.ExportRows(f)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	content, contentType, err := renderExport(f.Format, columns, data)
+	if err != nil {
+		log.Printf("❌ export render error: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename(f)))
+	w.Write(content)
+}
+
+func getOr(val, defaultVal string) string {
+	if val == "" {
+		return defaultVal
+	}
+	return val
+}
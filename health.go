@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ============ HEALTH CHECKS ============
+// /health/live  - proses hidup (liveness, selalu 200)
+// /health/ready - dependency wajib siap: journal DB (503 jika tidak). Telegram tidak ikut:
+//                 gangguan Telegram tidak boleh mencabut instance, EA tetap perlu /commands
+// /health       - laporan lengkap: Telegram, MT4 path, queue backlog, journal, EA heartbeat
+// Cek yang mahal (getMe, tulis file MT4) di-cache selama healthCacheTTL dan dijalankan
+// tanpa memegang lock: request lain mendapat hasil terakhir selama cek berjalan.
+
+const healthCacheTTL = 30 * time.Second
+
+type CheckResult struct {
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+	CheckedAt int64  `json:"checked_at"`
+}
+
+type cachedCheck struct {
+	mu      sync.Mutex
+	run     func() error
+	result  CheckResult
+	running bool
+}
+
+func (c *cachedCheck) get() CheckResult {
+	c.mu.Lock()
+	if c.running || time.Since(time.Unix(c.result.CheckedAt, 0)) < healthCacheTTL {
+		result := c.result
+		c.mu.Unlock()
+		if result.CheckedAt == 0 {
+			result.Error = "check in progress"
+		}
+		return result
+	}
+	c.running = true
+	c.mu.Unlock()
+
+	result := CheckResult{OK: true, CheckedAt: time.Now().Unix()}
+	if err := c.run(); err != nil {
+		result.OK = false
+		result.Error = err.Error()
+	}
+
+	c.mu.Lock()
+	c.result, c.running = result, false
+	c.mu.Unlock()
+	return result
+}
+
+var telegramHealth = &cachedCheck{run: checkTelegramConnection}
+var mt4Health = &cachedCheck{run: checkMT4Connection}
+
+// checkTelegramConnection - Panggil getMe untuk memastikan Bot API bisa dijangkau
+func checkTelegramConnection() error {
+	resp, err := telegramPost("getMe", "application/json", nil)
+	if err != nil {
+		return fmt.Errorf("telegram unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram getMe status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Ping - Cek koneksi journal DB
+func (j *Journal) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return j.db.PingContext(ctx)
+}
+
+// queueStats - Jumlah perintah di queue dan umur perintah tertua yang belum diambil EA
+func queueStats() (int, time.Duration) {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+
+	var oldest time.Duration
+	for _, cmd := range commandQueue {
+		if age := time.Since(cmd.enqueuedAt); !cmd.enqueuedAt.IsZero() && age > oldest {
+			oldest = age
+		}
+	}
+	return len(commandQueue), oldest
+}
+
+func journalHealth() CheckResult {
+	result := CheckResult{OK: true, CheckedAt: time.Now().Unix()}
+	if journal == nil {
+		result.Error = "disabled"
+		return result
+	}
+	if err := journal.Ping(); err != nil {
+		result.OK = false
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// readiness - Journal (jika aktif) harus OK agar siap menerima traffic
+func readiness() (bool, CheckResult) {
+	jr := journalHealth()
+	return jr.OK, jr
+}
+
+func writeHealth(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+// ============ HTTP: HEALTH ============
+func healthHandler(w http.ResponseWriter, r *http.Request) {
+	ready, jr := readiness()
+	tg := telegramHealth.get()
+	mt4 := mt4Health.get()
+	depth, oldest := queueStats()
+	offline := eaOfflineReason("")
+
+	// DEGRADED: jalan tapi ada masalah non-fatal (Telegram, MT4 path, EA offline, backlog macet)
+	stuck := config.HealthMaxQueueAgeSec > 0 && oldest > time.Duration(config.HealthMaxQueueAgeSec)*time.Second
+	status := "OK"
+	if !ready {
+		status = "DOWN"
+	} else if !tg.OK || !mt4.OK || offline != "" || stuck {
+		status = "DEGRADED"
+	}
+
+	writeHealth(w, http.StatusOK, map[string]interface{}{
+		"status":    status,
+		"timestamp": time.Now().Unix(),
+		"version":   "2.0.0",
+		"telegram":  tg,
+		"mt4": map[string]interface{}{
+			"path":       config.MT4DataPath,
+			"ok":         mt4.OK,
+			"error":      mt4.Error,
+			"checked_at": mt4.CheckedAt,
+		},
+		"journal": jr,
+		"queue": map[string]interface{}{
+			"depth":          depth,
+			"oldest_age_sec": int64(oldest.Seconds()),
+			"stuck":          stuck,
+		},
+		"ea": map[string]interface{}{
+			"online":    offline == "",
+			"reason":    offline,
+			"terminals": TerminalStatuses(),
+		},
+	})
+}
+
+// livenessHandler - Untuk restart container: cukup proses masih melayani HTTP
+func livenessHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, map[string]interface{}{"status": "alive", "timestamp": time.Now().Unix()})
+}
+
+// readinessHandler - Untuk load balancer / Render health check
+func readinessHandler(w http.ResponseWriter, r *http.Request) {
+	ready, jr := readiness()
+	code, status := http.StatusOK, "ready"
+	if !ready {
+		code, status = http.StatusServiceUnavailable, "not_ready"
+	}
+	writeHealth(w, code, map[string]interface{}{
+		"status":  status,
+		"journal": jr,
+	})
+}
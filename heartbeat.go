@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ============ EA HEARTBEAT ============
+// Catat poll /commands dan /signal terakhir per terminal (account MT4).
+// Jika gap poll melebihi EA_OFFLINE_AFTER_SEC, kirim peringatan Telegram dan
+// tandai (flag) atau tolak (refuse) tombol trade baru sampai EA kembali online.
+
+const defaultTerminal = "default" // EA lama yang belum mengirim account
+
+type terminalState struct {
+	Account    string
+	LastPoll   time.Time
+	LastSignal time.Time
+	alerted    bool // peringatan offline sudah dikirim
+}
+
+type TerminalStatus struct {
+	Account       string `json:"account"`
+	LastPoll      int64  `json:"last_poll,omitempty"`
+	LastSignal    int64  `json:"last_signal,omitempty"`
+	PollAgeSec    int64  `json:"poll_age_sec"` // -1 = belum pernah poll
+	Online        bool   `json:"online"`
+	OfflineNotice bool   `json:"offline_alerted"`
+}
+
+var terminalsMu sync.Mutex
+var terminals = map[string]*terminalState{}
+
+func terminalKey(account string) string {
+	if account == "" {
+		return defaultTerminal
+	}
+	return account
+}
+
+// getTerminal - Harus dipanggil dengan terminalsMu terkunci
+func getTerminal(account string) *terminalState {
+	key := terminalKey(account)
+	t := terminals[key]
+	if t == nil {
+		t = &terminalState{Account: key}
+		terminals[key] = t
+	}
+	return t
+}
+
+func eaOfflineThreshold() time.Duration {
+	return time.Duration(config.EAOfflineAfterSec) * time.Second
+}
+
+// recordTerminalPoll - Dipanggil setiap EA poll /commands
+func recordTerminalPoll(account string) {
+	terminalsMu.Lock()
+	t := getTerminal(account)
+	t.LastPoll = time.Now()
+	recovered := t.alerted
+	t.alerted = false
+	terminalsMu.Unlock()
+
+	if recovered {
+		log.Printf("💚 EA back online: account=%s", terminalKey(account))
+		notify(msgSystem, fmt.Sprintf("💚 EA back online\n🖥️ Account: %s", terminalKey(account)))
+	}
+}
+
+// recordTerminalSignal - Dipanggil setiap /signal yang lolos auth
+func recordTerminalSignal(account string) {
+	terminalsMu.Lock()
+	getTerminal(account).LastSignal = time.Now()
+	terminalsMu.Unlock()
+}
+
+// lastPollTime - Poll terbaru dari terminal mana pun (zero jika belum ada)
+func lastPollTime() time.Time {
+	terminalsMu.Lock()
+	defer terminalsMu.Unlock()
+
+	var latest time.Time
+	for _, t := range terminals {
+		if t.LastPoll.After(latest) {
+			latest = t.LastPoll
+		}
+	}
+	return latest
+}
+
+// eaOfflineReason - Alasan terminal dianggap offline, "" jika online / tidak diketahui.
+// Terminal yang belum pernah poll (mis. mode file bridge) tidak dianggap offline.
+// Tanpa account (atau account tanpa poll) dipakai poll terbaru dari terminal mana pun.
+func eaOfflineReason(account string) string {
+	if config.EAOfflineAfterSec <= 0 {
+		return ""
+	}
+
+	last := time.Time{}
+	terminalsMu.Lock()
+	if t := terminals[terminalKey(account)]; t != nil && account != "" {
+		last = t.LastPoll
+	}
+	terminalsMu.Unlock()
+	if last.IsZero() {
+		last = lastPollTime()
+	}
+	if last.IsZero() {
+		return ""
+	}
+
+	if gap := time.Since(last); gap > eaOfflineThreshold() {
+		return fmt.Sprintf("EA offline (last poll %s ago)", gap.Round(time.Second))
+	}
+	return ""
+}
+
+// signalOfflineReason - eaOfflineReason untuk account signal di pesan Telegram ini (dari journal)
+func signalOfflineReason(chatID int64, messageID int) string {
+	return eaOfflineReason(journal.SignalAccount(journal.SignalIDForMessage(chatID, messageID)))
+}
+
+// refuseIfEAOffline - Tolak tap tombol trade saat EA_OFFLINE_ACTION=refuse
+func refuseIfEAOffline(callback *TelegramCallbackQuery) bool {
+	if config.EAOfflineAction != "refuse" {
+		return false
+	}
+	reason := signalOfflineReason(callback.Message.Chat.ID, callback.Message.MessageID)
+	if reason == "" {
+		return false
+	}
+	log.Printf("📴 Trade refused: %s", reason)
+	answerCallbackQuery(callback.ID, "📴 "+reason)
+	return true
+}
+
+// buildOfflineSignal - Open signal saat EA offline: tombol disembunyikan (refuse) atau diberi tanda (flag)
+func buildOfflineSignal(p SignalPayload, ts string, reason string, lang string) (message, *TelegramInlineKeyboard) {
+	log.Printf("📴 Signal while EA offline: %s %s strat=%s reason=%s", p.Symbol, p.Side, p.Strategy, reason)
+
+	data := messageData{SignalPayload: p, Lang: lang, Time: ts, Note: reason, Buttons: config.EAOfflineAction != "refuse"}
+	if !data.Buttons {
+		return templateMessage("offline_signal", data), nil
+	}
+	return templateMessage("offline_signal", data), openSignalButtons(p)
+}
+
+// TerminalStatuses - Snapshot untuk /health
+func TerminalStatuses() []TerminalStatus {
+	terminalsMu.Lock()
+	defer terminalsMu.Unlock()
+
+	now := time.Now()
+	list := make([]TerminalStatus, 0, len(terminals))
+	for _, t := range terminals {
+		s := TerminalStatus{Account: t.Account, PollAgeSec: -1, Online: true, OfflineNotice: t.alerted}
+		if !t.LastPoll.IsZero() {
+			s.LastPoll = t.LastPoll.Unix()
+			s.PollAgeSec = int64(now.Sub(t.LastPoll).Seconds())
+			s.Online = config.EAOfflineAfterSec <= 0 || now.Sub(t.LastPoll) <= eaOfflineThreshold()
+		}
+		if !t.LastSignal.IsZero() {
+			s.LastSignal = t.LastSignal.Unix()
+		}
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, k int) bool { return list[i].Account < list[k].Account })
+	return list
+}
+
+// startHeartbeatMonitor - Cek gap poll setiap 15 detik; peringatan dikirim sekali per kejadian offline
+func startHeartbeatMonitor() {
+	if config.EAOfflineAfterSec <= 0 {
+		return
+	}
+	log.Printf("💓 EA heartbeat monitor: offline after %ds (action=%s)", config.EAOfflineAfterSec, config.EAOfflineAction)
+
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		var offline []terminalState
+		terminalsMu.Lock()
+		for _, t := range terminals {
+			if !t.LastPoll.IsZero() && !t.alerted && now.Sub(t.LastPoll) > eaOfflineThreshold() {
+				t.alerted = true
+				offline = append(offline, *t)
+			}
+		}
+		terminalsMu.Unlock()
+
+		for _, t := range offline {
+			gap := now.Sub(t.LastPoll).Round(time.Second)
+			log.Printf("📴 EA offline: account=%s last poll %s ago", t.Account, gap)
+			notify(msgError, fmt.Sprintf(
+				"📴 EA OFFLINE\n🖥️ Account: %s\n⏱️ Last poll: %s ago (%s)\n📝 Perintah baru tidak akan diambil sampai EA poll lagi.",
+				t.Account, gap, t.LastPoll.In(chatPrefs().location()).Format("15:04:05 MST"),
+			))
+		}
+	}
+}
@@ -0,0 +1,702 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ============ TRADE JOURNAL ============
+// Menghubungkan signal → keputusan user → command → ticket (ORDER_OPENED_CONFIRMATION)
+// → hasil (ORDER_CLOSED_CONFIRMATION). Semua method aman dipanggil saat journal nil.
+
+// Status signal
+const (
+	signalPending     = "pending"     // menunggu tap user
+	signalAuto        = "auto"        // di-enqueue otomatis (auto execute)
+	signalBlackout    = "blackout"    // masuk saat blackout / di luar sesi
+	signalOffline     = "offline"     // masuk saat EA tidak poll /commands
+	signalExecuted    = "executed"    // user memilih lot / close
+	signalIgnored     = "ignored"     // user menekan IGNORE
+	signalKept        = "kept"        // user memilih KEEP OPEN
+	signalCancelled   = "cancelled"   // auto execute dibatalkan
+	signalUndelivered = "undelivered" // gagal dikirim ke Telegram
+	signalExpired     = "expired"     // tidak ada aksi sampai SIGNAL_EXPIRY_MINUTES
+)
+
+// Status command
+const (
+	commandQueued    = "queued"
+	commandDelivered = "delivered"
+	commandCancelled = "cancelled"
+	commandFilled    = "filled"
+)
+
+const journalSchema = `
+CREATE TABLE IF NOT EXISTS signals (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	received_at INTEGER NOT NULL,
+	signal_ts   INTEGER,
+	symbol      TEXT,
+	side        TEXT,
+	strategy    TEXT,
+	timeframe   INTEGER,
+	price       REAL,
+	atr         REAL,
+	ticket      INTEGER,
+	status      TEXT NOT NULL,
+	note        TEXT,
+	chat_id     INTEGER,
+	message_id  INTEGER,
+	decided_by  INTEGER,
+	decided_at  INTEGER,
+	lots        REAL
+);
+CREATE INDEX IF NOT EXISTS idx_signals_message ON signals(chat_id, message_id);
+
+CREATE TABLE IF NOT EXISTS commands (
+	id           TEXT PRIMARY KEY,
+	signal_id    INTEGER REFERENCES signals(id),
+	action       TEXT NOT NULL,
+	symbol       TEXT,
+	side         TEXT,
+	lots         REAL,
+	price        REAL,
+	sl           REAL,
+	tp           REAL,
+	strategy     TEXT,
+	ticket       INTEGER,
+	source       TEXT,
+	user_id      INTEGER,
+	status       TEXT NOT NULL,
+	created_at   INTEGER NOT NULL,
+	delivered_at INTEGER,
+	updated_at   INTEGER
+);
+CREATE INDEX IF NOT EXISTS idx_commands_signal ON commands(signal_id);
+
+CREATE TABLE IF NOT EXISTS trades (
+	ticket      INTEGER PRIMARY KEY,
+	command_id  TEXT REFERENCES commands(id),
+	signal_id   INTEGER REFERENCES signals(id),
+	symbol      TEXT,
+	side        TEXT,
+	strategy    TEXT,
+	lots        REAL,
+	open_price  REAL,
+	opened_at   INTEGER,
+	close_price REAL,
+	closed_at   INTEGER,
+	profit      REAL,
+	currency    TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_trades_signal ON trades(signal_id);
+
+CREATE TABLE IF NOT EXISTS user_prefs (
+	user_id    INTEGER PRIMARY KEY,
+	prefs      TEXT NOT NULL,
+	updated_at INTEGER
+);
+
+CREATE TABLE IF NOT EXISTS source_offsets (
+	name        TEXT PRIMARY KEY,
+	path        TEXT NOT NULL,
+	byte_offset INTEGER NOT NULL,
+	updated_at  INTEGER
+);
+`
+
+// journalMigrations - Kolom yang ditambahkan setelah schema awal (error "duplicate column" diabaikan)
+var journalMigrations = []string{
+	`ALTER TABLE signals ADD COLUMN account TEXT`,
+	`ALTER TABLE trades ADD COLUMN account TEXT`,
+	`ALTER TABLE signals ADD COLUMN message_text TEXT`,
+}
+
+type Journal struct {
+	db *sql.DB
+}
+
+var journal *Journal
+
+func openJournal(path string) (*Journal, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create journal dir: %v", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(journalSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate journal: %v", err)
+	}
+	for _, migration := range journalMigrations {
+		if _, err := db.Exec(migration); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			db.Close()
+			return nil, fmt.Errorf("failed to migrate journal: %v", err)
+		}
+	}
+	return &Journal{db: db}, nil
+}
+
+func (j *Journal) exec(query string, args ...interface{}) (sql.Result, error) {
+	res, err := j.db.Exec(query, args...)
+	if err != nil {
+		log.Printf("⚠️ journal error: %v", err)
+	}
+	return res, err
+}
+
+// Close - Tutup DB saat shutdown
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	return j.db.Close()
+}
+
+// RecordSignal - Simpan signal yang dikirim ke Telegram, kembalikan ID signal
+func (j *Journal) RecordSignal(p SignalPayload, status, note string, chatID int64, messageID int) int64 {
+	if j == nil {
+		return 0
+	}
+	// Hanya close signal yang membawa ticket (ref2)
+	var ticket int64
+	if strings.HasPrefix(p.Side, "CLOSE_") {
+		ticket = int64(p.Ref2)
+	}
+	res, err := j.exec(
+		`INSERT INTO signals (received_at, signal_ts, account, symbol, side, strategy, timeframe, price, atr, ticket, status, note, chat_id, message_id)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		time.Now().Unix(), p.Timestamp, nullString(p.Account), p.Symbol, p.Side, p.Strategy, p.Timeframe, p.Price, p.ATR,
+		nullInt(ticket), status, note, chatID, messageID,
+	)
+	if err != nil {
+		return 0
+	}
+	id, _ := res.LastInsertId()
+	return id
+}
+
+// SetSignalMessage - Simpan pesan Telegram setelah signal terkirim lewat outbox
+func (j *Journal) SetSignalMessage(signalID int64, chatID int64, messageID int, text string) {
+	if j == nil || signalID == 0 {
+		return
+	}
+	j.exec(`UPDATE signals SET chat_id = ?, message_id = ?, message_text = ? WHERE id = ?`, chatID, messageID, text, signalID)
+}
+
+// SignalMessage - Pesan Telegram milik signal (untuk editMessageText)
+func (j *Journal) SignalMessage(signalID int64) (int64, int, string, bool) {
+	if j == nil || signalID == 0 {
+		return 0, 0, "", false
+	}
+	var chatID sql.NullInt64
+	var messageID sql.NullInt64
+	var text sql.NullString
+	err := j.db.QueryRow(`SELECT chat_id, message_id, message_text FROM signals WHERE id = ?`, signalID).Scan(&chatID, &messageID, &text)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("⚠️ journal error: %v", err)
+		}
+		return 0, 0, "", false
+	}
+	return chatID.Int64, int(messageID.Int64), text.String, true
+}
+
+// SignalAccount - Account MT4 dari signal (kosong jika tidak dikirim EA / tidak ada di journal)
+func (j *Journal) SignalAccount(signalID int64) string {
+	if j == nil || signalID == 0 {
+		return ""
+	}
+	var account sql.NullString
+	err := j.db.QueryRow(`SELECT account FROM signals WHERE id = ?`, signalID).Scan(&account)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("⚠️ journal error: %v", err)
+		}
+		return ""
+	}
+	return account.String
+}
+
+// UserPrefs - Preferensi user (JSON) dari /settings; ok=false jika belum pernah disimpan
+func (j *Journal) UserPrefs(userID int64) (string, bool) {
+	if j == nil {
+		return "", false
+	}
+	var prefs string
+	err := j.db.QueryRow(`SELECT prefs FROM user_prefs WHERE user_id = ?`, userID).Scan(&prefs)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("⚠️ journal error: %v", err)
+		}
+		return "", false
+	}
+	return prefs, true
+}
+
+// SaveUserPrefs - Simpan / ganti preferensi user
+func (j *Journal) SaveUserPrefs(userID int64, prefs string) {
+	if j == nil {
+		return
+	}
+	j.exec(`INSERT INTO user_prefs (user_id, prefs, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET prefs = excluded.prefs, updated_at = excluded.updated_at`,
+		userID, prefs, time.Now().Unix())
+}
+
+// SourceOffset - Posisi baca terakhir signal source jsonl; ok=false jika belum ada atau path berubah
+func (j *Journal) SourceOffset(name, path string) (int64, bool) {
+	if j == nil {
+		return 0, false
+	}
+	var offset int64
+	err := j.db.QueryRow(`SELECT byte_offset FROM source_offsets WHERE name = ? AND path = ?`, name, path).Scan(&offset)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("⚠️ journal error: %v", err)
+		}
+		return 0, false
+	}
+	return offset, true
+}
+
+// SaveSourceOffset - Simpan posisi baca signal source jsonl
+func (j *Journal) SaveSourceOffset(name, path string, offset int64) {
+	if j == nil {
+		return
+	}
+	j.exec(`INSERT INTO source_offsets (name, path, byte_offset, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET path = excluded.path, byte_offset = excluded.byte_offset, updated_at = excluded.updated_at`,
+		name, path, offset, time.Now().Unix())
+}
+
+// UpdateSignalText - Simpan teks terbaru setelah pesan di-edit
+func (j *Journal) UpdateSignalText(signalID int64, text string) {
+	if j == nil || signalID == 0 {
+		return
+	}
+	j.exec(`UPDATE signals SET message_text = ? WHERE id = ?`, text, signalID)
+}
+
+// ExpirePendingSignals - Tandai signal open pending yang lebih lama dari before sebagai expired, kembalikan ID-nya.
+// Signal close dan signal di skip (sedang menunggu konfirmasi) tidak disentuh.
+func (j *Journal) ExpirePendingSignals(before int64, skip map[int64]bool) []int64 {
+	if j == nil {
+		return nil
+	}
+	rows, err := j.db.Query(`SELECT id FROM signals WHERE status = ? AND received_at < ? AND message_id > 0 AND side NOT LIKE 'CLOSE_%'`, signalPending, before)
+	if err != nil {
+		log.Printf("⚠️ journal error: %v", err)
+		return nil
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil && !skip[id] {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		j.exec(`UPDATE signals SET status = ? WHERE id = ? AND status = ?`, signalExpired, id, signalPending)
+	}
+	return ids
+}
+
+// MarkSignalUndelivered - Outbox gagal mengirim signal (retry habis / ditolak Telegram)
+func (j *Journal) MarkSignalUndelivered(signalID int64, note string) {
+	if j == nil || signalID == 0 {
+		return
+	}
+	j.exec(`UPDATE signals SET status = ?, note = ? WHERE id = ?`, signalUndelivered, note, signalID)
+}
+
+// SignalIDForMessage - Cari signal berdasarkan pesan Telegram (dipakai oleh callback)
+func (j *Journal) SignalIDForMessage(chatID int64, messageID int) int64 {
+	if j == nil {
+		return 0
+	}
+	var id int64
+	err := j.db.QueryRow(`SELECT id FROM signals WHERE chat_id = ? AND message_id = ? ORDER BY id DESC LIMIT 1`, chatID, messageID).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("⚠️ journal error: %v", err)
+	}
+	return id
+}
+
+// RecordDecision - Catat keputusan user atas signal
+func (j *Journal) RecordDecision(signalID int64, status string, userID int64, lots float64) {
+	if j == nil || signalID == 0 {
+		return
+	}
+	j.exec(`UPDATE signals SET status = ?, decided_by = ?, decided_at = ?, lots = ? WHERE id = ?`,
+		status, nullInt(userID), time.Now().Unix(), nullFloat(lots), signalID)
+}
+
+// RecordCommand - Catat command yang masuk ke queue / file bridge
+func (j *Journal) RecordCommand(cmd TradeCommand, signalID int64, source string, userID int64) {
+	if j == nil || cmd.ID == "" {
+		return
+	}
+	now := time.Now().Unix()
+	j.exec(
+		`INSERT OR IGNORE INTO commands (id, signal_id, action, symbol, side, lots, price, sl, tp, strategy, ticket, source, user_id, status, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		cmd.ID, nullInt(signalID), cmd.Action, cmd.Symbol, cmd.Side, cmd.Lots, cmd.Price, cmd.SL, cmd.TP, cmd.Strategy,
+		nullInt(int64(cmd.Ticket)), source, nullInt(userID), commandQueued, now, now,
+	)
+}
+
+// MarkCommandsDelivered - Dipanggil saat EA mengambil command via /commands
+func (j *Journal) MarkCommandsDelivered(cmds []TradeCommand) {
+	if j == nil {
+		return
+	}
+	now := time.Now().Unix()
+	for _, cmd := range cmds {
+		if cmd.ID != "" {
+			j.exec(`UPDATE commands SET status = ?, delivered_at = ?, updated_at = ? WHERE id = ? AND status = ?`,
+				commandDelivered, now, now, cmd.ID, commandQueued)
+		}
+	}
+}
+
+// MarkCommandCancelled - Command dibatalkan sebelum diambil EA
+func (j *Journal) MarkCommandCancelled(id string, userID int64) {
+	if j == nil {
+		return
+	}
+	j.exec(`UPDATE commands SET status = ?, updated_at = ? WHERE id = ?`, commandCancelled, time.Now().Unix(), id)
+	j.exec(`UPDATE signals SET status = ?, decided_by = ?, decided_at = ? WHERE id = (SELECT signal_id FROM commands WHERE id = ?)`,
+		signalCancelled, nullInt(userID), time.Now().Unix(), id)
+}
+
+// RecordOpened - ORDER_OPENED_CONFIRMATION: ref1 = lots, ref2 = ticket, reason = strategy
+func (j *Journal) RecordOpened(p SignalPayload) int64 {
+	if j == nil {
+		return 0
+	}
+	ticket := int64(p.Ref2)
+	commandID := p.CommandID
+	if commandID == "" {
+		commandID = j.matchOpenCommand(p.Symbol, p.Side, p.Reason)
+	}
+
+	var signalID sql.NullInt64
+	if commandID != "" {
+		j.db.QueryRow(`SELECT signal_id FROM commands WHERE id = ?`, commandID).Scan(&signalID)
+		j.exec(`UPDATE commands SET ticket = ?, status = ?, updated_at = ? WHERE id = ?`, ticket, commandFilled, time.Now().Unix(), commandID)
+	}
+
+	j.exec(
+		`INSERT INTO trades (ticket, command_id, signal_id, account, symbol, side, strategy, lots, open_price, opened_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(ticket) DO UPDATE SET command_id = excluded.command_id, signal_id = excluded.signal_id,
+		   account = COALESCE(excluded.account, account), lots = excluded.lots, open_price = excluded.open_price, opened_at = excluded.opened_at`,
+		ticket, nullString(commandID), signalID, nullString(p.Account), p.Symbol, p.Side, p.Reason, p.Ref1, p.Price, signalTime(p),
+	)
+	return signalID.Int64
+}
+
+// matchOpenCommand - Fallback bila EA tidak mengirim command_id: command open terbaru yang cocok
+func (j *Journal) matchOpenCommand(symbol, side, strategy string) string {
+	var id string
+	err := j.db.QueryRow(
+		`SELECT id FROM commands
+		 WHERE action = 'open' AND ticket IS NULL AND status IN (?, ?) AND side = ? AND strategy = ?
+		   AND (symbol = ? OR ? LIKE symbol || '%' OR symbol LIKE ? || '%')
+		 ORDER BY created_at DESC LIMIT 1`,
+		commandQueued, commandDelivered, side, strategy, symbol, symbol, symbol,
+	).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		log.Printf("⚠️ journal error: %v", err)
+	}
+	return id
+}
+
+// RecordClosed - ORDER_CLOSED_CONFIRMATION: ref1 = open price, ref2 = ticket, reason = lots;profit;currency
+func (j *Journal) RecordClosed(p SignalPayload, lots, profit float64, currency string) int64 {
+	if j == nil {
+		return 0
+	}
+	ticket := int64(p.Ref2)
+	now := time.Now().Unix()
+	j.exec(
+		`INSERT INTO trades (ticket, account, symbol, side, lots, open_price, close_price, closed_at, profit, currency)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(ticket) DO UPDATE SET account = COALESCE(excluded.account, account), close_price = excluded.close_price,
+		   closed_at = excluded.closed_at, profit = excluded.profit, currency = excluded.currency`,
+		ticket, nullString(p.Account), p.Symbol, p.Side, lots, p.Ref1, p.Price, signalTime(p), profit, currency,
+	)
+	j.exec(`UPDATE commands SET status = ?, updated_at = ? WHERE action = 'close' AND ticket = ? AND status IN (?, ?)`,
+		commandFilled, now, ticket, commandQueued, commandDelivered)
+
+	var signalID sql.NullInt64
+	j.db.QueryRow(`SELECT signal_id FROM trades WHERE ticket = ?`, ticket).Scan(&signalID)
+	return signalID.Int64
+}
+
+// ---- Query ----
+
+type JournalSignal struct {
+	ID         int64   `json:"id"`
+	ReceivedAt int64   `json:"received_at"`
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"`
+	Strategy   string  `json:"strategy"`
+	Price      float64 `json:"price"`
+	Status     string  `json:"status"`
+	Note       string  `json:"note,omitempty"`
+	DecidedBy  int64   `json:"decided_by,omitempty"`
+	DecidedAt  int64   `json:"decided_at,omitempty"`
+	Lots       float64 `json:"lots,omitempty"`
+}
+
+type JournalCommand struct {
+	ID          string  `json:"id"`
+	Action      string  `json:"action"`
+	Symbol      string  `json:"symbol"`
+	Side        string  `json:"side,omitempty"`
+	Lots        float64 `json:"lots,omitempty"`
+	Ticket      int64   `json:"ticket,omitempty"`
+	Source      string  `json:"source"`
+	UserID      int64   `json:"user_id,omitempty"`
+	Status      string  `json:"status"`
+	CreatedAt   int64   `json:"created_at"`
+	DeliveredAt int64   `json:"delivered_at,omitempty"`
+}
+
+type JournalTrade struct {
+	Ticket     int64   `json:"ticket"`
+	CommandID  string  `json:"command_id,omitempty"`
+	SignalID   int64   `json:"signal_id,omitempty"`
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"`
+	Strategy   string  `json:"strategy,omitempty"`
+	Lots       float64 `json:"lots"`
+	OpenPrice  float64 `json:"open_price"`
+	OpenedAt   int64   `json:"opened_at,omitempty"`
+	ClosePrice float64 `json:"close_price,omitempty"`
+	ClosedAt   int64   `json:"closed_at,omitempty"`
+	Profit     float64 `json:"profit,omitempty"`
+	Currency   string  `json:"currency,omitempty"`
+}
+
+type SignalHistory struct {
+	Signal   JournalSignal    `json:"signal"`
+	Commands []JournalCommand `json:"commands"`
+	Trades   []JournalTrade   `json:"trades"`
+}
+
+const journalSignalColumns = `id, received_at, COALESCE(symbol, ''), COALESCE(side, ''), COALESCE(strategy, ''), COALESCE(price, 0),
+	status, COALESCE(note, ''), COALESCE(decided_by, 0), COALESCE(decided_at, 0), COALESCE(lots, 0)`
+
+func scanJournalSignal(row interface{ Scan(...interface{}) error }) (JournalSignal, error) {
+	var s JournalSignal
+	err := row.Scan(&s.ID, &s.ReceivedAt, &s.Symbol, &s.Side, &s.Strategy, &s.Price, &s.Status, &s.Note, &s.DecidedBy, &s.DecidedAt, &s.Lots)
+	return s, err
+}
+
+// RecentStrategies - Nama strategi open signal sejak waktu tertentu
+func (j *Journal) RecentStrategies(since int64) ([]string, error) {
+	if j == nil {
+		return nil, nil
+	}
+	rows, err := j.db.Query(`SELECT DISTINCT strategy FROM signals WHERE received_at >= ? AND side NOT LIKE 'CLOSE_%' AND strategy != ''`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// RecentSignals - Signal terbaru (paling baru di depan)
+func (j *Journal) RecentSignals(limit int) ([]JournalSignal, error) {
+	rows, err := j.db.Query(`SELECT `+journalSignalColumns+` FROM signals ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	signals := []JournalSignal{}
+	for rows.Next() {
+		s, err := scanJournalSignal(rows)
+		if err != nil {
+			return nil, err
+		}
+		signals = append(signals, s)
+	}
+	return signals, rows.Err()
+}
+
+// SignalHistory - "Apa yang terjadi dengan signal ini?"
+func (j *Journal) SignalHistory(id int64) (*SignalHistory, error) {
+	s, err := scanJournalSignal(j.db.QueryRow(`SELECT `+journalSignalColumns+` FROM signals WHERE id = ?`, id))
+	if err != nil {
+		return nil, err
+	}
+	history := &SignalHistory{Signal: s, Commands: []JournalCommand{}, Trades: []JournalTrade{}}
+
+	rows, err := j.db.Query(
+		`SELECT id, action, COALESCE(symbol, ''), COALESCE(side, ''), COALESCE(lots, 0), COALESCE(ticket, 0),
+		        COALESCE(source, ''), COALESCE(user_id, 0), status, created_at, COALESCE(delivered_at, 0)
+		 FROM commands WHERE signal_id = ? ORDER BY created_at`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c JournalCommand
+		if err := rows.Scan(&c.ID, &c.Action, &c.Symbol, &c.Side, &c.Lots, &c.Ticket, &c.Source, &c.UserID, &c.Status, &c.CreatedAt, &c.DeliveredAt); err != nil {
+			return nil, err
+		}
+		history.Commands = append(history.Commands, c)
+	}
+
+	trades, err := j.queryTrades(`WHERE signal_id = ? OR ticket = (SELECT ticket FROM signals WHERE id = ?) ORDER BY opened_at`, id, id)
+	if err != nil {
+		return nil, err
+	}
+	history.Trades = append(history.Trades, trades...)
+	return history, nil
+}
+
+func (j *Journal) queryTrades(where string, args ...interface{}) ([]JournalTrade, error) {
+	rows, err := j.db.Query(
+		`SELECT ticket, COALESCE(command_id, ''), COALESCE(signal_id, 0), COALESCE(symbol, ''), COALESCE(side, ''),
+		        COALESCE(strategy, ''), COALESCE(lots, 0), COALESCE(open_price, 0), COALESCE(opened_at, 0),
+		        COALESCE(close_price, 0), COALESCE(closed_at, 0), COALESCE(profit, 0), COALESCE(currency, '')
+		 FROM trades `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trades []JournalTrade
+	for rows.Next() {
+		var t JournalTrade
+		if err := rows.Scan(&t.Ticket, &t.CommandID, &t.SignalID, &t.Symbol, &t.Side, &t.Strategy, &t.Lots,
+			&t.OpenPrice, &t.OpenedAt, &t.ClosePrice, &t.ClosedAt, &t.Profit, &t.Currency); err != nil {
+			return nil, err
+		}
+		trades = append(trades, t)
+	}
+	return trades, rows.Err()
+}
+
+// ---- Helpers ----
+
+// journalCallback - Catat keputusan user dari callback Telegram (dan command bila ada)
+func journalCallback(callback *TelegramCallbackQuery, status string, lots float64, cmd *TradeCommand) {
+	if journal == nil {
+		return
+	}
+	signalID := journal.SignalIDForMessage(callback.Message.Chat.ID, callback.Message.MessageID)
+	journal.RecordDecision(signalID, status, callback.From.ID, lots)
+	if cmd != nil {
+		journal.RecordCommand(*cmd, signalID, "manual", callback.From.ID)
+	}
+}
+
+func signalTime(p SignalPayload) int64 {
+	if p.Timestamp > 0 {
+		return p.Timestamp
+	}
+	return time.Now().Unix()
+}
+
+func nullInt(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
+
+func nullFloat(v float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: v, Valid: v != 0}
+}
+
+func nullString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+// ============ HTTP: JOURNAL ============
+// GET /journal?token=...            → signal terbaru
+// GET /journal?token=...&signal=ID  → riwayat lengkap satu signal
+func journalHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !authorizeAPIRequest(w, r) {
+		return
+	}
+
+	if journal == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("journal disabled"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if idStr := r.URL.Query().Get("signal"); idStr != "" {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		history, err := journal.SignalHistory(id)
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		} else if err != nil {
+			log.Printf("❌ journal query error: %v", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(history)
+		return
+	}
+
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+	signals, err := journal.RecentSignals(limit)
+	if err != nil {
+		log.Printf("❌ journal query error: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ok":      true,
+		"count":   len(signals),
+		"signals": signals,
+	})
+}
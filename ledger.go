@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============ SIGNAL LEDGER ============
+// Pesan signal di Telegram ditulis ulang (editMessageText) dengan hasilnya:
+// dieksekusi / diabaikan / dibatalkan / kadaluarsa, lalu ticket dan P&L saat
+// confirmation dari EA masuk. Teks terakhir disimpan di journal agar edit berikutnya
+// bisa menambahkan baris baru.
+
+// editMessageText - Ganti teks pesan; tanpa reply_markup keyboard ikut hilang
+func editMessageText(chatID int64, messageID int, text string) error {
+	return editMessage(chatID, messageID, text, nil)
+}
+
+// editMessage - Ganti teks dan keyboard pesan (buttons nil = hapus keyboard)
+func editMessage(chatID int64, messageID int, text string, buttons *TelegramInlineKeyboard) error {
+	return editMessageMode(chatID, messageID, text, "", buttons)
+}
+
+// editSignalMessage - Edit pesan signal (hasil template, memakai TELEGRAM_PARSE_MODE)
+func editSignalMessage(chatID int64, messageID int, text string, buttons *TelegramInlineKeyboard) error {
+	return editMessageMode(chatID, messageID, text, config.ParseMode, buttons)
+}
+
+func editMessageMode(chatID int64, messageID int, text, parseMode string, buttons *TelegramInlineKeyboard) error {
+	payload := map[string]interface{}{
+		"chat_id":    chatID,
+		"message_id": messageID,
+		"text":       text,
+	}
+	if parseMode != "" {
+		payload["parse_mode"] = parseMode
+	}
+	if buttons != nil {
+		payload["reply_markup"] = buttons
+	}
+	b, _ := json.Marshal(payload)
+	resp, err := telegramPost("editMessageText", "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		apiErr := parseTelegramError(resp.StatusCode, resp.Body)
+		if strings.Contains(apiErr.Description, "message is not modified") {
+			return nil
+		}
+		return apiErr
+	}
+	return nil
+}
+
+// userLabel - @username, atau ID jika user tidak punya username
+func userLabel(callback *TelegramCallbackQuery) string {
+	if callback.From.Username != "" {
+		return "@" + callback.From.Username
+	}
+	return fmt.Sprintf("user %d", callback.From.ID)
+}
+
+// signalText - Teks signal tersimpan di journal (sudah dalam markup), atau teks polos dari Telegram di-escape
+func signalText(chatID int64, messageID int, fallbackText string) string {
+	if _, _, saved, ok := journal.SignalMessage(journal.SignalIDForMessage(chatID, messageID)); ok && saved != "" {
+		return saved
+	}
+	return escapeMarkup(config.ParseMode, fallbackText)
+}
+
+// signalHintMarker - Awal baris instruksi di template signal; baris lain (mis. 📝 alasan close) tidak disentuh
+const signalHintMarker = "👉 "
+
+// rewriteSignalMessage - Buang baris instruksi (👉 ...) dan tambahkan hasil (teks biasa, di-escape) di bawah
+func rewriteSignalMessage(text, outcome string) string {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if !strings.HasPrefix(line, signalHintMarker) {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n") + "\n" + escapeMarkup(config.ParseMode, outcome)
+}
+
+// messageLock - Mutex per pesan signal (dengan refcount agar map tidak tumbuh terus)
+type messageLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+var (
+	messageLocksMu sync.Mutex
+	messageLocks   = map[string]*messageLock{}
+)
+
+// lockSignalMessage - Serialisasi baca-tambah-tulis teks satu pesan signal: callback dan
+// confirmation EA berjalan di goroutine berbeda dan bisa mengedit pesan yang sama bersamaan
+func lockSignalMessage(chatID int64, messageID int) func() {
+	key := fmt.Sprintf("%d:%d", chatID, messageID)
+	messageLocksMu.Lock()
+	l, ok := messageLocks[key]
+	if !ok {
+		l = &messageLock{}
+		messageLocks[key] = l
+	}
+	l.refs++
+	messageLocksMu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		messageLocksMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(messageLocks, key)
+		}
+		messageLocksMu.Unlock()
+	}
+}
+
+// markMessageOutcome - Tulis hasil ke pesan signal; fallbackText dipakai jika journal tidak punya teksnya.
+// Tanpa teks sama sekali, cukup hapus keyboard seperti sebelumnya.
+func markMessageOutcome(chatID int64, messageID int, fallbackText, outcome string) {
+	unlock := lockSignalMessage(chatID, messageID)
+	defer unlock()
+
+	signalID := journal.SignalIDForMessage(chatID, messageID)
+	text := signalText(chatID, messageID, fallbackText)
+
+	if text == "" {
+		if err := removeInlineKeyboard(chatID, messageID); err != nil {
+			log.Printf("⚠️ removeInlineKeyboard error: %v", err)
+		}
+		return
+	}
+
+	text = rewriteSignalMessage(text, outcome)
+	if err := editSignalMessage(chatID, messageID, text, nil); err != nil {
+		log.Printf("⚠️ editMessageText error: %v", err)
+		return
+	}
+	journal.UpdateSignalText(signalID, text)
+}
+
+// markCallbackOutcome - Hasil dari tombol yang ditekan user
+func markCallbackOutcome(callback *TelegramCallbackQuery, outcome string) {
+	markMessageOutcome(callback.Message.Chat.ID, callback.Message.MessageID, callback.Message.Text, outcome)
+}
+
+// appendSignalOutcome - Tambah baris ke pesan signal dari journal (ticket, close P&L, expired)
+func appendSignalOutcome(signalID int64, outcome string) {
+	chatID, messageID, _, ok := journal.SignalMessage(signalID)
+	if !ok || messageID == 0 {
+		return
+	}
+	unlock := lockSignalMessage(chatID, messageID)
+	defer unlock()
+
+	// Baca ulang teks di dalam lock: edit lain bisa selesai sejak query pertama
+	_, _, text, ok := journal.SignalMessage(signalID)
+	if !ok || text == "" {
+		return
+	}
+	text = rewriteSignalMessage(text, outcome)
+	if err := editSignalMessage(chatID, messageID, text, nil); err != nil {
+		log.Printf("⚠️ editMessageText error: %v", err)
+		return
+	}
+	journal.UpdateSignalText(signalID, text)
+}
+
+// startSignalExpiry - Signal yang tidak disentuh selama SIGNAL_EXPIRY_MINUTES ditandai kadaluarsa
+func startSignalExpiry() {
+	if journal == nil || config.SignalExpiryMinutes <= 0 {
+		return
+	}
+	ttl := time.Duration(config.SignalExpiryMinutes) * time.Minute
+	log.Printf("⌛ Signal expiry: %s", ttl)
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		for _, id := range journal.ExpirePendingSignals(now.Add(-ttl).Unix(), confirmingSignalIDs()) {
+			appendSignalOutcome(id, fmt.Sprintf("⌛ Expired — no action within %d min", config.SignalExpiryMinutes))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewriteSignalMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		text    string
+		outcome string
+		want    string
+	}{
+		{
+			name:    "hint removed",
+			text:    "🟢 OPEN SIGNAL\n📊 XAUUSD BUY\n👉 Pilih lot:\n🕐 10:00:00 WIB",
+			outcome: "✅ 0.10 lot by @budi",
+			want:    "🟢 OPEN SIGNAL\n📊 XAUUSD BUY\n🕐 10:00:00 WIB\n✅ 0.10 lot by @budi",
+		},
+		{
+			name:    "close reason kept",
+			text:    "🔴 CLOSE SIGNAL\n📝 EMA cross down\n🕐 10:00:00 WIB",
+			outcome: "⏳ Kept open",
+			want:    "🔴 CLOSE SIGNAL\n📝 EMA cross down\n🕐 10:00:00 WIB\n⏳ Kept open",
+		},
+		{
+			name:    "marker only at line start",
+			text:    "🟢 OPEN SIGNAL\n📊 note 👉 inline",
+			outcome: "❌ Ignored",
+			want:    "🟢 OPEN SIGNAL\n📊 note 👉 inline\n❌ Ignored",
+		},
+		{
+			name:    "second outcome appended",
+			text:    "🟢 OPEN SIGNAL\n✅ 0.10 lot by @budi",
+			outcome: "🎫 Ticket #123",
+			want:    "🟢 OPEN SIGNAL\n✅ 0.10 lot by @budi\n🎫 Ticket #123",
+		},
+		{
+			name:    "html outcome escaped",
+			mode:    parseModeHTML,
+			text:    "<b>🟢 OPEN SIGNAL</b>\n👉 Pilih lot:",
+			outcome: "❌ Failed: <EOF> & retry",
+			want:    "<b>🟢 OPEN SIGNAL</b>\n❌ Failed: &lt;EOF&gt; &amp; retry",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testConfig(t, Config{ParseMode: tt.mode})
+			if got := rewriteSignalMessage(tt.text, tt.outcome); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRewriteSignalTemplates - Template bawaan: baris hint hilang, alasan close tetap ada
+func TestRewriteSignalTemplates(t *testing.T) {
+	testConfig(t, Config{TelegramChatID: "-100123", DisplayTimezone: "UTC", Language: "en", TemplatesDir: t.TempDir()})
+	old := templates
+	t.Cleanup(func() { templates = old })
+	if err := loadTemplates(); err != nil {
+		t.Fatalf("loadTemplates: %v", err)
+	}
+
+	tests := []struct {
+		template string
+		buttons  bool
+		keep     string
+	}{
+		{template: "open_signal", buttons: true},
+		{template: "open_signal", buttons: false},
+		{template: "blackout_signal", buttons: true},
+		{template: "offline_signal", buttons: true},
+		{template: "offline_signal", buttons: false},
+		{template: "auto_execute", buttons: true},
+		{template: "auto_blocked", buttons: true},
+		{template: "close_signal", buttons: true, keep: "📝 News blackout: USD NFP (30 min)"},
+	}
+	for _, tt := range tests {
+		for _, lang := range supportedLanguages {
+			data := sampleMessageData(lang)
+			data.Buttons = tt.buttons
+			text, err := renderMessage(tt.template, data, "")
+			if err != nil {
+				t.Fatalf("%s/%s: %v", tt.template, lang, err)
+			}
+			if tt.keep == "" && !strings.Contains(text, signalHintMarker) {
+				t.Errorf("%s/%s buttons=%v: no hint line\n%s", tt.template, lang, tt.buttons, text)
+			}
+			got := rewriteSignalMessage(text, "✅ done")
+			if strings.Contains(got, signalHintMarker) {
+				t.Errorf("%s/%s buttons=%v: hint left in\n%s", tt.template, lang, tt.buttons, got)
+			}
+			if !strings.HasSuffix(got, "\n✅ done") {
+				t.Errorf("%s/%s: outcome not appended\n%s", tt.template, lang, got)
+			}
+			if tt.keep != "" && !strings.Contains(got, tt.keep) {
+				t.Errorf("%s/%s: %q removed\n%s", tt.template, lang, tt.keep, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ============ LOCALIZATION ============
+// Katalog teks per bahasa (id / en), dipakai template pesan lewat {{.T "key"}}.
+// Bahasa dan timezone diambil dari preferensi pemilik chat (/settings), default
+// DEFAULT_LANGUAGE dan DISPLAY_TIMEZONE. Key yang tidak ada di bahasa user jatuh
+// ke "en", lalu ke key itu sendiri.
+
+const (
+	langID = "id"
+	langEN = "en"
+)
+
+var supportedLanguages = []string{langID, langEN}
+
+var catalogs = map[string]map[string]string{
+	langEN: {
+		"title.open":     "[OPEN SIGNAL]",
+		"title.blackout": "[OPEN SIGNAL - BLACKOUT]",
+		"title.offline":  "[OPEN SIGNAL - EA OFFLINE]",
+		"title.auto":     "[AUTO EXECUTE]",
+		"title.close":    "[CLOSE SIGNAL]",
+		"title.opened":   "[ORDER OPENED]",
+		"title.closed":   "[ORDER CLOSED]",
+		"title.account":  "Account",
+		"title.orders":   "Active Orders",
+
+		"label.price":     "Price",
+		"label.lots":      "Lots",
+		"label.lots_unit": "lots",
+		"label.ticket":    "Ticket",
+		"label.strategy":  "Strategy",
+		"label.open":      "Open",
+		"label.close":     "Close",
+		"label.free":      "Free",
+
+		"hint.pick_lot":         "Choose a lot size below to execute.",
+		"hint.buttons_disabled": "Execution buttons disabled.",
+		"hint.auto":             "Order sent to the EA automatically. Press CANCEL before the EA picks it up.",
+		"hint.auto_sent":        "Order sent to the EA automatically.",
+		"warn.offline_wait":     "%s - commands will wait until the EA polls again.",
+		"warn.auto_blocked":     "Auto execute blocked: %s",
+	},
+	langID: {
+		"title.open":     "[SIGNAL OPEN]",
+		"title.blackout": "[SIGNAL OPEN - BLACKOUT]",
+		"title.offline":  "[SIGNAL OPEN - EA OFFLINE]",
+		"title.auto":     "[EKSEKUSI OTOMATIS]",
+		"title.close":    "[SIGNAL CLOSE]",
+		"title.opened":   "[ORDER TERBUKA]",
+		"title.closed":   "[ORDER DITUTUP]",
+		"title.account":  "Akun",
+		"title.orders":   "Order Aktif",
+
+		"label.price":     "Harga",
+		"label.lots":      "Lot",
+		"label.lots_unit": "lot",
+		"label.ticket":    "Tiket",
+		"label.strategy":  "Strategi",
+		"label.open":      "Buka",
+		"label.close":     "Tutup",
+		"label.free":      "Bebas",
+
+		"hint.pick_lot":         "Pilih ukuran lot di bawah untuk eksekusi.",
+		"hint.buttons_disabled": "Tombol eksekusi dinonaktifkan.",
+		"hint.auto":             "Order otomatis dikirim ke EA. Tekan CANCEL sebelum EA mengambil perintah.",
+		"hint.auto_sent":        "Order otomatis dikirim ke EA.",
+		"warn.offline_wait":     "%s - perintah akan menunggu sampai EA poll lagi.",
+		"warn.auto_blocked":     "Auto execute diblokir: %s",
+	},
+}
+
+// normalizeLanguage - "EN", "en-US" → "en"; bahasa tidak dikenal → ""
+func normalizeLanguage(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return ""
+}
+
+// T - Terjemahkan key lalu format dengan args (fmt.Sprintf)
+func T(lang, key string, args ...interface{}) string {
+	format, ok := catalogs[lang][key]
+	if !ok {
+		if format, ok = catalogs[langEN][key]; !ok {
+			format = key
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -14,6 +16,7 @@ import (
 	"time"
 
 	"sync"
+	"sync/atomic"
 
 	"github.com/joho/godotenv"
 )
@@ -25,6 +28,94 @@ type Config struct {
 	APIAuthToken     string
 	Port             string
 	MT4DataPath      string
+
+	// Role Telegram (user ID dipisah koma); keduanya kosong = semua user admin
+	TelegramAdminIDs  map[string]bool
+	TelegramTraderIDs map[string]bool
+
+	// Auto execute: strategi terpercaya langsung di-enqueue tanpa tap manual
+	AutoExecuteStrategies map[string]bool
+	AutoExecuteLots       float64
+
+	// Risk guards (0 = nonaktif)
+	MaxLotSize      float64
+	MaxPendingOpens int
+
+	// Konfirmasi 2 langkah untuk trade manual besar/berisiko (0 = nonaktif)
+	ConfirmLotsAbove float64
+	ConfirmRiskPct   float64
+	AccountBalance   float64 // fallback sebelum EA mengirim ACCOUNT_INFO
+
+	// Unit per 1 lot untuk hitung risiko (emas 100 oz, forex 100.000)
+	GoldContractSize  float64
+	ForexContractSize float64
+
+	// Batas lot custom (override per symbol: LOT_STEP_XAUUSD, MIN_LOT_XAUUSD, MAX_LOT_XAUUSD)
+	LotStep float64
+	MinLot  float64
+	MaxLot  float64
+
+	// Default preferensi user (/settings): preset tombol lot dan risiko % (0 = tanpa tombol risk)
+	LotPresets     []float64
+	DefaultRiskPct float64
+
+	// Tampilan default: timezone (IANA) dan bahasa pesan (id | en); per user via /settings
+	DisplayTimezone string
+	Language        string
+
+	// Routing notifikasi ke beberapa chat (lihat routing.go), kosong = semua ke TELEGRAM_CHAT_ID
+	NotifyRoutes string
+
+	// TradingView /tv: token (default API_AUTH_TOKEN), file mapping dan rate limit (lihat tradingview.go)
+	TVToken       string
+	TVMappingFile string
+	TVRateLimit   int
+
+	// Signal source tambahan: http / jsonl (lihat sources.go)
+	SignalSourcesFile string
+
+	// Outbound event webhooks (lihat events.go)
+	EventWebhooks       string
+	EventWebhookSecret  string
+	EventMaxRetries     int
+	EventDeadLetterPath string
+
+	// Template pesan: folder override (hot reload) dan parse mode Telegram (kosong | HTML | MarkdownV2)
+	TemplatesDir string
+	ParseMode    string
+
+	// Jadwal trading: sesi mingguan, hari libur, dan blackout berita
+	ScheduleTimezone        string
+	TradingSessions         string
+	Holidays                string
+	NewsCalendarFile        string
+	NewsBlackoutMinutes     int
+	BlackoutSuppressButtons bool
+
+	JournalDBPath string
+
+	// Laporan P&L terjadwal (jam DISPLAY_TIMEZONE, "off" = nonaktif)
+	DailyReportTime  string
+	WeeklyReportDay  string
+	WeeklyReportTime string
+
+	// EA heartbeat: gap poll /commands (detik, 0 = nonaktif) dan aksi saat offline (flag | refuse)
+	EAOfflineAfterSec int
+	EAOfflineAction   string
+
+	// Health: perintah tertua di queue lebih dari N detik = DEGRADED (0 = nonaktif)
+	HealthMaxQueueAgeSec int
+
+	// File queue perintah yang disimpan saat shutdown ("off" = nonaktif)
+	QueueStatePath     string
+	QueueMaxOpenAgeSec int // open yang dipulihkan lebih tua dari ini dibuang (0 = tanpa batas)
+
+	// Signal tanpa aksi ditandai kadaluarsa setelah N menit (0 = nonaktif)
+	SignalExpiryMinutes int
+
+	// Outbox Telegram: jeda minimum per chat dan jumlah retry sebelum pesan dianggap gagal
+	TelegramChatIntervalMs int
+	TelegramMaxRetries     int
 }
 
 func loadConfig() *Config {
@@ -34,6 +125,74 @@ func loadConfig() *Config {
 		APIAuthToken:     getEnv("API_AUTH_TOKEN", "changeme"),
 		Port:             getEnv("PORT", ":8080"),
 		MT4DataPath:      getEnv("MT4_DATA_PATH", getDefaultMT4Path()),
+
+		TelegramAdminIDs:  getEnvSet("TELEGRAM_ADMIN_IDS"),
+		TelegramTraderIDs: getEnvSet("TELEGRAM_TRADER_IDS"),
+
+		AutoExecuteStrategies: getEnvSet("AUTO_EXECUTE_STRATEGIES"),
+		AutoExecuteLots:       getEnvFloat("AUTO_EXECUTE_LOTS", 0.1),
+
+		MaxLotSize:      getEnvFloat("MAX_LOT_SIZE", 0),
+		MaxPendingOpens: getEnvInt("MAX_PENDING_OPENS", 0),
+
+		ConfirmLotsAbove: getEnvFloat("CONFIRM_LOTS_ABOVE", 0.5),
+		ConfirmRiskPct:   getEnvFloat("CONFIRM_RISK_PCT", 2.0),
+		AccountBalance:   getEnvFloat("ACCOUNT_BALANCE", 0),
+
+		GoldContractSize:  getEnvFloat("GOLD_CONTRACT_SIZE", 100),
+		ForexContractSize: getEnvFloat("FOREX_CONTRACT_SIZE", 100000),
+
+		LotStep: getEnvFloat("LOT_STEP", 0.01),
+		MinLot:  getEnvFloat("MIN_LOT", 0.01),
+		MaxLot:  getEnvFloat("MAX_LOT", 100),
+
+		LotPresets:     getEnvFloats("LOT_PRESETS", []float64{0.1, 0.2, 0.5, 0.7, 1.0}),
+		DefaultRiskPct: getEnvFloat("DEFAULT_RISK_PCT", 0),
+
+		DisplayTimezone: getEnv("DISPLAY_TIMEZONE", "Asia/Jakarta"),
+		Language:        getOr(normalizeLanguage(getEnv("DEFAULT_LANGUAGE", langID)), langID),
+
+		NotifyRoutes: getEnv("NOTIFY_ROUTES", ""),
+
+		TVToken:       getEnv("TV_TOKEN", getEnv("API_AUTH_TOKEN", "changeme")),
+		TVMappingFile: getEnv("TV_MAPPING_FILE", ""),
+		TVRateLimit:   getEnvInt("TV_RATE_LIMIT", 0),
+
+		SignalSourcesFile: getEnv("SIGNAL_SOURCES_FILE", ""),
+
+		EventWebhooks:       getEnv("EVENT_WEBHOOKS", ""),
+		EventWebhookSecret:  getEnv("EVENT_WEBHOOK_SECRET", ""),
+		EventMaxRetries:     getEnvInt("EVENT_WEBHOOK_MAX_RETRIES", 5),
+		EventDeadLetterPath: getEnv("EVENT_DEADLETTER_PATH", "./data/events-deadletter.jsonl"),
+
+		TemplatesDir: getEnv("TEMPLATES_DIR", "./templates"),
+		ParseMode:    normalizeParseMode(getEnv("TELEGRAM_PARSE_MODE", "")),
+
+		ScheduleTimezone:        getEnv("SCHEDULE_TIMEZONE", "Asia/Jakarta"),
+		TradingSessions:         getEnv("TRADING_SESSIONS", ""),
+		Holidays:                getEnv("HOLIDAYS", ""),
+		NewsCalendarFile:        getEnv("NEWS_CALENDAR_FILE", ""),
+		NewsBlackoutMinutes:     getEnvInt("NEWS_BLACKOUT_MINUTES", 30),
+		BlackoutSuppressButtons: getEnvBool("BLACKOUT_SUPPRESS_BUTTONS", true),
+
+		JournalDBPath: getEnv("JOURNAL_DB_PATH", "./data/journal.db"),
+
+		DailyReportTime:  getEnv("DAILY_REPORT_TIME", "23:55"),
+		WeeklyReportDay:  getEnv("WEEKLY_REPORT_DAY", "SAT"),
+		WeeklyReportTime: getEnv("WEEKLY_REPORT_TIME", "06:00"),
+
+		EAOfflineAfterSec: getEnvInt("EA_OFFLINE_AFTER_SEC", 60),
+		EAOfflineAction:   strings.ToLower(getEnv("EA_OFFLINE_ACTION", "flag")),
+
+		HealthMaxQueueAgeSec: getEnvInt("HEALTH_MAX_QUEUE_AGE_SEC", 300),
+
+		QueueStatePath:     getEnv("QUEUE_STATE_PATH", "./data/queue.json"),
+		QueueMaxOpenAgeSec: getEnvInt("QUEUE_MAX_OPEN_AGE_SEC", 300),
+
+		SignalExpiryMinutes: getEnvInt("SIGNAL_EXPIRY_MINUTES", 60),
+
+		TelegramChatIntervalMs: getEnvInt("TELEGRAM_CHAT_INTERVAL_MS", 1000),
+		TelegramMaxRetries:     getEnvInt("TELEGRAM_MAX_RETRIES", 8),
 	}
 }
 
@@ -62,6 +221,40 @@ func getEnvFloat(key string, defaultVal float64) float64 {
 	return defaultVal
 }
 
+func getEnvBool(key string, defaultVal bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if boolVal, err := strconv.ParseBool(val); err == nil {
+			return boolVal
+		}
+	}
+	return defaultVal
+}
+
+// getEnvSet - Parse daftar dipisah koma (mis. "EMA_PULLBACK,VWAP") menjadi set uppercase
+func getEnvSet(key string) map[string]bool {
+	set := map[string]bool{}
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.ToUpper(strings.TrimSpace(item)); item != "" {
+			set[item] = true
+		}
+	}
+	return set
+}
+
+// getEnvFloats - Parse daftar angka dipisah koma (mis. "0.1,0.2,0.5"); nilai invalid diabaikan
+func getEnvFloats(key string, defaultVal []float64) []float64 {
+	var vals []float64
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(item), 64); err == nil && v > 0 {
+			vals = append(vals, v)
+		}
+	}
+	if len(vals) == 0 {
+		return defaultVal
+	}
+	return vals
+}
+
 func getDefaultMT4Path() string {
 	// Check if MT4_DATA_PATH is set (for Render deployment)
 	if mt4Path := os.Getenv("MT4_DATA_PATH"); mt4Path != "" {
@@ -102,11 +295,14 @@ type SignalPayload struct {
 	ATR       float64 `json:"atr,omitempty"`
 	Reason    string  `json:"reason,omitempty"`
 	Timestamp int64   `json:"timestamp"`
+	CommandID string  `json:"command_id,omitempty"` // diisi EA pada ORDER_OPENED_CONFIRMATION
+	Account   string  `json:"account,omitempty"`    // nomor akun MT4
 }
 
 type TelegramMessage struct {
 	ChatID      string                  `json:"chat_id"`
 	Text        string                  `json:"text"`
+	ParseMode   string                  `json:"parse_mode,omitempty"`
 	ReplyMarkup *TelegramInlineKeyboard `json:"reply_markup,omitempty"`
 }
 
@@ -122,21 +318,24 @@ type TelegramInlineButton struct {
 type TelegramCallbackQuery struct {
 	ID   string `json:"id"`
 	From struct {
-		ID int64 `json:"id"`
+		ID       int64  `json:"id"`
+		Username string `json:"username,omitempty"`
 	} `json:"from"`
 	Data    string `json:"data"`
 	Message struct {
-		MessageID int `json:"message_id"`
-		Chat      struct {
+		MessageID   int                     `json:"message_id"`
+		Text        string                  `json:"text"`
+		ReplyMarkup *TelegramInlineKeyboard `json:"reply_markup,omitempty"`
+		Chat        struct {
 			ID int64 `json:"id"`
 		} `json:"chat"`
 	} `json:"message"`
 }
 
 type TelegramUpdate struct {
-	UpdateID      int                    `json:"update_id"`
-	Message       *TelegramMessage       `json:"message,omitempty"`
-	CallbackQuery *TelegramCallbackQuery `json:"callback_query,omitempty"`
+	UpdateID      int                      `json:"update_id"`
+	Message       *TelegramIncomingMessage `json:"message,omitempty"`
+	CallbackQuery *TelegramCallbackQuery   `json:"callback_query,omitempty"`
 }
 
 type TradeCommand struct {
@@ -149,41 +348,118 @@ type TradeCommand struct {
 	TP       float64 `json:"tp"`
 	Strategy string  `json:"strategy"`
 	Ticket   int     `json:"ticket,omitempty"`
+	ID       string  `json:"id,omitempty"`
+
+	enqueuedAt time.Time // untuk metrics delivery latency
+}
+
+type TelegramSentMessage struct {
+	MessageID int `json:"message_id"`
+	Chat      struct {
+		ID int64 `json:"id"`
+	} `json:"chat"`
 }
 
 // ============ GLOBALS ============
 var config *Config
 var queueMu sync.Mutex
 var commandQueue []TradeCommand
+var commandSeq uint64
 
 // ============ TELEGRAM FUNCTIONS ============
-func sendTelegramWithButtons(text string, buttons *TelegramInlineKeyboard) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", config.TelegramBotToken)
-	msg := TelegramMessage{ChatID: config.TelegramChatID, Text: text, ReplyMarkup: buttons}
+// telegramClient - Semua panggilan Bot API; timeout agar satu request macet tidak menahan outbox / health
+var telegramClient = &http.Client{Timeout: 30 * time.Second}
+
+// telegramPost - POST ke Bot API dengan metrics latency/error per method
+func telegramPost(method, contentType string, body io.Reader) (*http.Response, error) {
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/%s", config.TelegramBotToken, method)
+	start := time.Now()
+	resp, err := telegramClient.Post(url, contentType, body)
+	metricTelegramLatency.Observe(time.Since(start).Seconds(), method)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		metricTelegramErrors.Inc(method)
+	}
+	return resp, err
+}
+
+// sendTelegramMessage - Kirim pesan dan kembalikan message yang terkirim (untuk edit/hapus tombol nanti)
+// parseMode kosong = teks biasa; HTML / MarkdownV2 hanya untuk pesan dari template
+func sendTelegramMessage(chatID, text, parseMode string, buttons *TelegramInlineKeyboard) (*TelegramSentMessage, error) {
+	msg := TelegramMessage{ChatID: chatID, Text: text, ParseMode: parseMode, ReplyMarkup: buttons}
 	b, _ := json.Marshal(msg)
-	resp, err := http.Post(url, "application/json", bytes.NewReader(b))
+	resp, err := telegramPost("sendMessage", "application/json", bytes.NewReader(b))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("telegram send failed: %s", resp.Status)
+		return nil, parseTelegramError(resp.StatusCode, resp.Body)
 	}
-	return nil
+
+	var result struct {
+		Result TelegramSentMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		log.Printf("⚠️ sendMessage response decode error: %v", err)
+	}
+	return &result.Result, nil
 }
 
-func sendTelegram(text string) error {
-	return sendTelegramWithButtons(text, nil)
+// sendTelegramWithButtons - Kirim ke TELEGRAM_CHAT_ID lewat notifier default (outbox: async, berurutan,
+// dengan retry). Tidak ada error di sini: kegagalan kirim dicatat oleh outbox.
+func sendTelegramWithButtons(text string, buttons *TelegramInlineKeyboard) {
+	defaultNotifier().Notify(notification{Message: plainMessage(text), Buttons: buttons})
+}
+
+func sendTelegram(text string) {
+	sendTelegramWithButtons(text, nil)
+}
+
+// sendTelegramWithButtonsTo - Seperti sendTelegramWithButtons ke chat tertentu (balasan di chat asal command / prompt)
+func sendTelegramWithButtonsTo(chatID int64, text string, buttons *TelegramInlineKeyboard) {
+	telegramNotifier{ChatID: strconv.FormatInt(chatID, 10)}.Notify(notification{Message: plainMessage(text), Buttons: buttons})
+}
+
+func sendTelegramTo(chatID int64, text string) {
+	sendTelegramWithButtonsTo(chatID, text, nil)
+}
+
+// sendTelegramFile - Upload file (multipart) ke chat, method = sendDocument / sendPhoto
+func sendTelegramFile(chatID, method, field, filename string, data []byte, caption string) error {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("chat_id", chatID)
+	if caption != "" {
+		form.WriteField("caption", caption)
+	}
+	part, err := form.CreateFormFile(field, filename)
+	if err != nil {
+		return err
+	}
+	part.Write(data)
+	form.Close()
+
+	resp, err := telegramPost(method, form.FormDataContentType(), &body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s failed: %s", method, resp.Status)
+	}
+	return nil
 }
 
 func answerCallbackQuery(callbackQueryID, text string) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/answerCallbackQuery", config.TelegramBotToken)
+	if callbackQueryID == "" {
+		return nil // callback sintetis (mis. balasan custom lot), tidak ada yang dijawab
+	}
 	payload := map[string]string{
 		"callback_query_id": callbackQueryID,
 		"text":              text,
 	}
 	b, _ := json.Marshal(payload)
-	resp, err := http.Post(url, "application/json", bytes.NewReader(b))
+	resp, err := telegramPost("answerCallbackQuery", "application/json", bytes.NewReader(b))
 	if err != nil {
 		return err
 	}
@@ -192,14 +468,13 @@ func answerCallbackQuery(callbackQueryID, text string) error {
 }
 
 func removeInlineKeyboard(chatID int64, messageID int) error {
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/editMessageReplyMarkup", config.TelegramBotToken)
 	payload := map[string]interface{}{
 		"chat_id":      chatID,
 		"message_id":   messageID,
 		"reply_markup": map[string]interface{}{}, // empty object removes keyboard
 	}
 	b, _ := json.Marshal(payload)
-	resp, err := http.Post(url, "application/json", bytes.NewReader(b))
+	resp, err := telegramPost("editMessageReplyMarkup", "application/json", bytes.NewReader(b))
 	if err != nil {
 		return err
 	}
@@ -227,11 +502,41 @@ func sendTradeToMT4(trade TradeCommand) error {
 	return nil
 }
 
-func enqueueTrade(trade TradeCommand) {
+// newCommandID - ID unik untuk melacak perintah di queue (mis. untuk cancel)
+func newCommandID() string {
+	return fmt.Sprintf("%d-%d", time.Now().Unix(), atomic.AddUint64(&commandSeq, 1))
+}
+
+// enqueueTrade - Enqueue tanpa risk guard (mis. bulk close); ditolak jika queue sudah disimpan untuk shutdown
+func enqueueTrade(trade TradeCommand) error {
 	queueMu.Lock()
 	defer queueMu.Unlock()
+	if queueClosed {
+		return errQueueClosed
+	}
+	appendTradeLocked(trade)
+	return nil
+}
+
+// appendTradeLocked - Tambah perintah ke queue; pemanggil memegang queueMu
+func appendTradeLocked(trade TradeCommand) {
+	trade.enqueuedAt = time.Now()
 	commandQueue = append(commandQueue, trade)
 	log.Printf("📥 Enqueued trade for HTTP bridge: %s %s %.2f lots", trade.Symbol, trade.Side, trade.Lots)
+	publishEvent(eventCommandEnqueued, trade)
+}
+
+// cancelQueuedCommand - Hapus perintah dari queue selama belum diambil EA
+func cancelQueuedCommand(id string) (TradeCommand, bool) {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	for i, cmd := range commandQueue {
+		if cmd.ID == id {
+			commandQueue = append(commandQueue[:i], commandQueue[i+1:]...)
+			return cmd, true
+		}
+	}
+	return TradeCommand{}, false
 }
 
 func sendCloseToMT4(ticket int, symbol string) error {
@@ -252,15 +557,25 @@ func sendCloseToMT4(ticket int, symbol string) error {
 	return nil
 }
 
-func enqueueClose(ticket int, symbol string, strategy string) {
+func enqueueClose(ticket int, symbol string, strategy string) TradeCommand {
 	queueMu.Lock()
 	defer queueMu.Unlock()
-	commandQueue = append(commandQueue, TradeCommand{Action: "close", Ticket: ticket, Symbol: symbol, Strategy: strategy})
+	cmd := TradeCommand{Action: "close", Ticket: ticket, Symbol: symbol, Strategy: strategy, ID: newCommandID(), enqueuedAt: time.Now()}
+	commandQueue = append(commandQueue, cmd)
 	if strategy != "" {
 		log.Printf("📥 Enqueued close for HTTP bridge: ticket #%d strategy=%s", ticket, strategy)
 	} else {
 		log.Printf("📥 Enqueued close for HTTP bridge: ticket #%d", ticket)
 	}
+	publishEvent(eventCommandEnqueued, cmd)
+	return cmd
+}
+
+// enqueueStatus - Minta EA mengirim daftar order aktif (ORDERS_STATUS)
+func enqueueStatus() {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	commandQueue = append(commandQueue, TradeCommand{Action: "status", enqueuedAt: time.Now()})
 }
 
 // ============ MT4 BRIDGE FUNCTIONS ============
@@ -419,6 +734,19 @@ func calculateDynamicSLTP(symbol, side string, price float64, atr float64) (sl,
 }
 
 // ============ HTTP HANDLERS ============
+// openSignalButtons - Keyboard pilihan lot untuk open signal
+func openSignalButtons(p SignalPayload) *TelegramInlineKeyboard {
+	signalData := fmt.Sprintf("%s|%s|%.2f|%s|%.2f", p.Symbol, p.Side, p.Price, p.Strategy, p.ATR)
+	rows := [][]TelegramInlineButton{
+		{
+			{Text: "❌ IGNORE", CallbackData: "ignore|" + signalData},
+		},
+	}
+	rows = append(rows, lotButtonRows(signalData, chatPrefs())...)
+	rows = append(rows, []TelegramInlineButton{{Text: "📋 ACTIVE ORDERS", CallbackData: "status"}})
+	return &TelegramInlineKeyboard{InlineKeyboard: rows}
+}
+
 func signalHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -433,57 +761,86 @@ func signalHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if p.Token != config.APIAuthToken {
+		metricAuthRejected.Inc("/signal")
 		w.WriteHeader(http.StatusUnauthorized)
 		fmt.Fprint(w, "unauthorized")
 		return
 	}
+	recordTerminalSignal(p.Account)
+	processSignal(w, p)
+}
 
-	// Format timestamps in WIB (Asia/Jakarta)
-	loc, err := time.LoadLocation("Asia/Jakarta")
-	if err != nil {
-		loc = time.FixedZone("WIB", 7*60*60)
+// processSignal - Pipeline bersama semua sumber signal (EA, TradingView, sources.go): pesan Telegram,
+// journal, routing, auto execute. Respons JSON ditulis ke w.
+func processSignal(w http.ResponseWriter, p SignalPayload) {
+	metricSignals.Inc(p.Strategy, signalType(p))
+
+	// /pause dan /strategies disable hanya menahan open signal; confirmation & close tetap diproses
+	if signalType(p) == "open" {
+		if signalsPaused.Load() {
+			log.Printf("⏸️ Signal rejected (paused): %s %s %s", p.Symbol, p.Side, p.Strategy)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"ok":false,"paused":true}`))
+			return
+		}
+		if isStrategyDisabled(p.Strategy) {
+			log.Printf("🚫 Signal skipped (strategy disabled): %s %s %s", p.Symbol, p.Side, p.Strategy)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true,"skipped":"strategy disabled"}`))
+			return
+		}
 	}
-	ts := time.Unix(p.Timestamp, 0).In(loc).Format("15:04:05 WIB")
+
+	// Waktu dan bahasa sesuai preferensi pemilik chat (default DISPLAY_TIMEZONE / DEFAULT_LANGUAGE)
+	prefs := chatPrefs()
+	lang := prefs.Language
+	ts := time.Unix(p.Timestamp, 0).In(prefs.location()).Format("15:04:05 MST")
 
 	// Handle different signal types
-	var msg string
+	var msg message
 	var buttons *TelegramInlineKeyboard
+	var autoTrade *TradeCommand
+	journalStatus, journalNote := "", "" // kosong = bukan signal (confirmation/status)
+	msgType := msgOpen                   // jenis pesan untuk NOTIFY_ROUTES
 
 	// Handle open confirmation
 	if p.Strategy == "ORDER_OPENED_CONFIRMATION" {
 		// ref1 = lots, ref2 = ticket, reason = original strategy
+		msgType = msgConfirmation
 		lots := p.Ref1
-		msg = fmt.Sprintf(
-			"✅ [ORDER OPENED]\n🎫 Ticket: #%.0f\n📊 %s %s %.2f lots\n💰 Entry: %.2f\n🎯 Strategy: %s\n🕐 %s",
-			p.Ref2, p.Symbol, p.Side, lots, p.Price, p.Reason, ts,
-		)
+		signalID := journal.RecordOpened(p)
+		if signalID != 0 {
+			go appendSignalOutcome(signalID, fmt.Sprintf("🎫 Ticket #%.0f opened @ %.2f (%.2f lots)", p.Ref2, p.Price, lots))
+		}
+		publishEvent(eventOrderOpened, orderEvent{
+			SignalID: signalID, Ticket: int64(p.Ref2), Account: p.Account, Symbol: p.Symbol, Side: p.Side,
+			Strategy: p.Reason, Lots: lots, Price: p.Price,
+		})
+		msg = templateMessage("order_opened", messageData{SignalPayload: p, Lang: lang, Time: ts, Note: p.Reason, Lots: lots})
 
 		signalData := fmt.Sprintf("%s|%s|%.2f|%s|%.2f", p.Symbol, p.Side, p.Price, p.Reason, p.ATR)
-		buttons = &TelegramInlineKeyboard{
-			InlineKeyboard: [][]TelegramInlineButton{
-				{
-					{Text: "❌ DONE", CallbackData: "ignore|" + signalData},
-				},
-				{
-					{Text: "📊 0.1 LOT", CallbackData: "lot|0.1|" + signalData},
-					{Text: "📊 0.2 LOT", CallbackData: "lot|0.2|" + signalData},
-					{Text: "📊 0.5 LOT", CallbackData: "lot|0.5|" + signalData},
-				},
-				{
-					{Text: "📊 0.7 LOT", CallbackData: "lot|0.7|" + signalData},
-					{Text: "📊 1.0 LOT", CallbackData: "lot|1.0|" + signalData},
-				},
+		rows := [][]TelegramInlineButton{
+			{
+				{Text: "❌ DONE", CallbackData: "ignore|" + signalData},
 			},
 		}
+		buttons = &TelegramInlineKeyboard{InlineKeyboard: append(rows, lotButtonRows(signalData, prefs)...)}
 
 		// Handle close confirmation
 	} else if p.Strategy == "ORDER_CLOSED_CONFIRMATION" {
 		// Parse lots;profit;currency from reason field
+		msgType = msgConfirmation
 		reasonParts := strings.Split(p.Reason, ";")
 		if len(reasonParts) >= 3 {
 			lots, _ := strconv.ParseFloat(reasonParts[0], 64)
 			profit, _ := strconv.ParseFloat(reasonParts[1], 64)
 			currency := reasonParts[2]
+			signalID := journal.RecordClosed(p, lots, profit, currency)
+			publishEvent(eventOrderClosed, orderEvent{
+				SignalID: signalID, Ticket: int64(p.Ref2), Account: p.Account, Symbol: p.Symbol, Side: p.Side,
+				Lots: lots, Price: p.Price, OpenPrice: p.Ref1, Profit: profit, Currency: currency,
+			})
 
 			profitEmoji := "✅"
 			profitSign := ""
@@ -492,17 +849,21 @@ func signalHandler(w http.ResponseWriter, r *http.Request) {
 			} else if profit > 0 {
 				profitSign = "+"
 			}
+			if signalID != 0 {
+				go appendSignalOutcome(signalID, fmt.Sprintf("🏁 #%.0f closed @ %.2f — P&L %s%.2f %s", p.Ref2, p.Price, profitSign, profit, currency))
+			}
 
-			msg = fmt.Sprintf(
-				"%s [ORDER CLOSED]\n🎫 Ticket: #%.0f\n📊 %s %s %.2f lots\n💰 Open: %.2f\n💰 Close: %.2f\n💵 P&L: %s%.2f %s\n🕐 %s",
-				profitEmoji, p.Ref2, p.Symbol, p.Side, lots, p.Ref1, p.Price, profitSign, profit, currency, ts,
-			)
+			msg = templateMessage("order_closed", messageData{
+				SignalPayload: p, Lang: lang, Time: ts,
+				Lots: lots, Profit: profit, Currency: currency, Emoji: profitEmoji,
+			})
 
 			// No buttons for confirmation
 			buttons = nil
 		}
 	} else if strings.HasPrefix(p.Side, "CLOSE_") {
 		// CLOSE SIGNAL
+		msgType = msgClose
 		actualSide := strings.TrimPrefix(p.Side, "CLOSE_")
 
 		// Parse P&L dari reason field jika tersedia
@@ -521,31 +882,18 @@ func signalHandler(w http.ResponseWriter, r *http.Request) {
 			reasonText = p.Reason
 		}
 
-		// Format P&L
-		plSign := ""
 		plEmoji := "📈"
 		if floatingPL < 0 {
-			plSign = "-"
 			plEmoji = "📉"
-		} else if floatingPL > 0 {
-			plSign = "+"
-			plEmoji = "📈"
 		}
 
-		// Build message dengan P&L
-		if len(reasonParts) >= 3 {
-			msg = fmt.Sprintf(
-				"🔴 [CLOSE SIGNAL]\n📊 %s %s\n🎯 %s\n💰 Price: %.2f\n📍 Entry: %.2f\n%s P&L: %s%.2f %s\n📝 %s\n🕐 %s",
-				p.Symbol, actualSide, p.Strategy, p.Price, p.Ref1, plEmoji, plSign, floatingPL, currency, reasonText, ts,
-			)
-		} else {
-			// Fallback untuk format lama
-			msg = fmt.Sprintf(
-				"🔴 [CLOSE SIGNAL]\n📊 %s %s\n🎯 %s\n💰 Price: %.2f\n📍 Entry: %.2f\n📝 %s\n🕐 %s",
-				p.Symbol, actualSide, p.Strategy, p.Price, p.Ref1, reasonText, ts,
-			)
-		}
+		// Format lama (tanpa P&L) cukup reason saja
+		msg = templateMessage("close_signal", messageData{
+			SignalPayload: p, Lang: lang, Time: ts, Note: reasonText, CloseSide: actualSide,
+			Profit: floatingPL, HasPL: len(reasonParts) >= 3, Currency: currency, Emoji: plEmoji,
+		})
 
+		journalStatus = signalPending
 		actualStrategy := strings.TrimPrefix(p.Strategy, "CLOSE_")
 		closeData := fmt.Sprintf("%s|%.0f|%s", p.Symbol, p.Ref2, actualStrategy) // Ref2 = ticket
 		buttons = &TelegramInlineKeyboard{
@@ -556,49 +904,77 @@ func signalHandler(w http.ResponseWriter, r *http.Request) {
 				},
 			},
 		}
+	} else if p.Strategy == "ACCOUNT_INFO" {
+		// EA menjawab /balance
+		msgType = msgAccount
+		recordAccountInfo(p)
+		msg = formatAccountInfo(p, ts, lang)
+		buttons = nil
 	} else if p.Strategy == "ORDERS_STATUS" {
 		// EA pushed active orders status in Reason
-		msg = fmt.Sprintf("📋 Active Orders\n%s", p.Reason)
+		msgType = msgAccount
+		msg = templateMessage("orders_status", messageData{SignalPayload: p, Lang: lang, Time: ts})
 		buttons = nil
+	} else if reason := tradingBlockedReason(time.Now()); reason != "" {
+		// OPEN SIGNAL di luar sesi trading / saat blackout
+		msg, buttons = buildBlackoutSignal(p, ts, reason, lang)
+		journalStatus, journalNote = signalBlackout, reason
+	} else if reason := eaOfflineReason(p.Account); reason != "" {
+		// OPEN SIGNAL saat EA offline (auto execute juga ditahan)
+		msg, buttons = buildOfflineSignal(p, ts, reason, lang)
+		journalStatus, journalNote = signalOffline, reason
+	} else if isAutoExecuteStrategy(p.Strategy) {
+		// OPEN SIGNAL (auto execute)
+		msg, buttons, autoTrade = buildAutoExecuteSignal(p, ts, lang)
+		journalStatus = signalPending
+		if autoTrade != nil {
+			journalStatus = signalAuto
+		}
 	} else {
 		// OPEN SIGNAL
-		msg = fmt.Sprintf(
-			"🚨 [OPEN SIGNAL]\n📊 %s\n📈 %s\n🎯 %s\n💰 Price: %.2f\n📝 Pilih ukuran lot di bawah untuk eksekusi.\n🕐 %s",
-			p.Symbol, p.Side, p.Strategy, p.Price, ts,
-		)
+		msg = templateMessage("open_signal", messageData{SignalPayload: p, Lang: lang, Time: ts, Buttons: true})
 
-		signalData := fmt.Sprintf("%s|%s|%.2f|%s|%.2f", p.Symbol, p.Side, p.Price, p.Strategy, p.ATR)
-		buttons = &TelegramInlineKeyboard{
-			InlineKeyboard: [][]TelegramInlineButton{
-				{
-					{Text: "❌ IGNORE", CallbackData: "ignore|" + signalData},
-				},
-				{
-					{Text: "📊 0.1 LOT", CallbackData: "lot|0.1|" + signalData},
-					{Text: "📊 0.2 LOT", CallbackData: "lot|0.2|" + signalData},
-					{Text: "📊 0.5 LOT", CallbackData: "lot|0.5|" + signalData},
-				},
-				{
-					{Text: "📊 0.7 LOT", CallbackData: "lot|0.7|" + signalData},
-					{Text: "📊 1.0 LOT", CallbackData: "lot|1.0|" + signalData},
-				},
-				{
-					{Text: "📋 ACTIVE ORDERS", CallbackData: "status"},
-				},
-			},
-		}
+		buttons = openSignalButtons(p)
+		journalStatus = signalPending
+	}
+
+	var signalID int64
+	if journalStatus != "" {
+		signalID = journal.RecordSignal(p, journalStatus, journalNote, 0, 0)
+		publishEvent(eventSignalReceived, signalEvent{SignalID: signalID, Status: journalStatus, Note: journalNote, Signal: p})
 	}
 
-	if err := sendTelegramWithButtons(msg, buttons); err != nil {
-		log.Printf("❌ Telegram error: %v", err)
-		w.WriteHeader(http.StatusBadGateway)
+	// Verbosity "actionable": signal tanpa tombol (blackout / EA offline) cukup dicatat di journal
+	if prefs.Verbosity == verbosityActionable && buttons == nil && (journalStatus == signalBlackout || journalStatus == signalOffline) {
+		log.Printf("🔕 Signal not sent (verbosity=actionable): %s %s %s", p.Symbol, p.Side, p.Strategy)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true,"queued":false}`))
 		return
 	}
 
-	log.Printf("📱 Signal sent: %s %s", p.Side, p.Strategy)
+	// Pesan masuk outbox per route; message ID dan auto execute diproses setelah salinan utama terkirim
+	dispatchRouted(msgType, p, msg, buttons, func(sent *TelegramSentMessage, sentMsg message, err error) {
+		if err != nil {
+			log.Printf("❌ Telegram error: %v", err)
+			journal.MarkSignalUndelivered(signalID, err.Error())
+			return
+		}
+		journal.SetSignalMessage(signalID, sent.Chat.ID, sent.MessageID, sentMsg.render(config.ParseMode))
+
+		// Auto execute baru di-enqueue setelah pesan (dengan tombol CANCEL) terkirim
+		if autoTrade != nil {
+			journal.RecordCommand(*autoTrade, signalID, "auto", 0)
+			if err := enqueueAutoExecute(*autoTrade, sent.Chat.ID, sent.MessageID); err != nil {
+				journal.MarkCommandCancelled(autoTrade.ID, 0)
+			}
+		}
+		log.Printf("📱 Signal sent: %s %s", p.Side, p.Strategy)
+	})
+
+	log.Printf("📨 Signal queued: %s %s", p.Side, p.Strategy)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"ok":true}`))
+	w.Write([]byte(`{"ok":true,"queued":true}`))
 }
 
 func webhookHandler(w http.ResponseWriter, r *http.Request) {
@@ -617,18 +993,22 @@ func webhookHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if update.CallbackQuery != nil {
+		if !allowedChat(update.CallbackQuery.Message.Chat.ID, update.CallbackQuery.From.ID) {
+			log.Printf("🚫 Callback from unconfigured chat %d (user %d) ignored", update.CallbackQuery.Message.Chat.ID, update.CallbackQuery.From.ID)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
 		log.Printf("🧲 CallbackQuery: id=%s chat=%d msgId=%d data=%q", update.CallbackQuery.ID, update.CallbackQuery.Message.Chat.ID, update.CallbackQuery.Message.MessageID, update.CallbackQuery.Data)
 		handleCallbackQuery(update.CallbackQuery)
 	} else if update.Message != nil {
-		// Handle text commands (no-buttons)
-		text := strings.TrimSpace(update.Message.Text)
-		if strings.HasPrefix(text, "/orders") || strings.HasPrefix(text, "/status") {
-			queueMu.Lock()
-			commandQueue = append(commandQueue, TradeCommand{Action: "status"})
-			queueMu.Unlock()
-			_ = sendTelegram("📋 Fetching active orders...")
-		} else {
-			log.Printf("💬 Non-callback message received: %q", text)
+		if !allowedChat(update.Message.Chat.ID, update.Message.From.ID) {
+			log.Printf("🚫 Message from unconfigured chat %d (user %d) ignored", update.Message.Chat.ID, update.Message.From.ID)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		// Balasan prompt custom lot / settings, selain itu text command
+		if !handleCustomLotReply(update.Message) && !handleSettingsReply(update.Message) {
+			handleTextCommand(update.Message)
 		}
 	}
 
@@ -644,112 +1024,21 @@ func handleCallbackQuery(callback *TelegramCallbackQuery) {
 
 	action := parts[0]
 	log.Printf("🎛️  Action=%s raw=%q", action, callback.Data)
+	metricCallbacks.Inc(action)
 
-	switch action {
-	case "trade":
-		if len(parts) >= 5 {
-			symbol := parts[1]
-			side := parts[2]
-			price, _ := strconv.ParseFloat(parts[3], 64)
-			strategy := parts[4]
-
-			lots := 0.1
-			// Gunakan SL/TP dinamis jika tersedia, fallback ke fixed
-			var sl, tp float64
-			if len(parts) >= 6 {
-				// ATR tersedia dari EA
-				atr, _ := strconv.ParseFloat(parts[5], 64)
-				if atr > 0 {
-					sl, tp = calculateDynamicSLTP(symbol, side, price, atr)
-					log.Printf("🟢 TRADE request (dynamic): %s %s price=%.2f lots=%.1f sl=%.2f tp=%.2f atr=%.2f strat=%s", symbol, side, price, lots, sl, tp, atr, strategy)
-				} else {
-					// ATR invalid, fallback ke fixed
-					sl, tp = calculateSLTP(symbol, side, price)
-					log.Printf("🟢 TRADE request (fixed, invalid ATR): %s %s price=%.2f lots=%.1f sl=%.2f tp=%.2f strat=%s", symbol, side, price, lots, sl, tp, strategy)
-				}
-			} else {
-				// Fallback ke fixed SL/TP
-				sl, tp = calculateSLTP(symbol, side, price)
-				log.Printf("🟢 TRADE request (fixed): %s %s price=%.2f lots=%.1f sl=%.2f tp=%.2f strat=%s", symbol, side, price, lots, sl, tp, strategy)
-			}
-
-			trade := TradeCommand{
-				Action:   "open",
-				Symbol:   symbol,
-				Side:     side,
-				Lots:     lots,
-				Price:    price,
-				SL:       sl,
-				TP:       tp,
-				Strategy: strategy,
-			}
-
-			if err := sendTradeToMT4(trade); err != nil {
-				answerCallbackQuery(callback.ID, "❌ Trade failed")
-				sendTelegram("❌ Trade failed: " + err.Error())
-				log.Printf("❌ sendTradeToMT4 error: %v", err)
-			} else {
-				answerCallbackQuery(callback.ID, "✅ Trade sent!")
-				sendTelegram(fmt.Sprintf("✅ Trade: %s %s %.1f lots @ %.2f", symbol, side, lots, price))
-				log.Printf("✅ Trade command dispatched to MT4")
-				// Remove inline buttons from the original message (best-effort)
-				if err := removeInlineKeyboard(callback.Message.Chat.ID, callback.Message.MessageID); err != nil {
-					log.Printf("⚠️ removeInlineKeyboard error: %v", err)
-				}
-			}
-			enqueueTrade(trade)
-		}
-
-	case "lot":
-		if len(parts) >= 6 {
-			lots, _ := strconv.ParseFloat(parts[1], 64)
-			symbol := parts[2]
-			side := parts[3]
-			price, _ := strconv.ParseFloat(parts[4], 64)
-			strategy := parts[5]
-
-			// Gunakan SL/TP dinamis jika tersedia, fallback ke fixed
-			var sl, tp float64
-			if len(parts) >= 7 {
-				// ATR tersedia dari EA
-				atr, _ := strconv.ParseFloat(parts[6], 64)
-				if atr > 0 {
-					sl, tp = calculateDynamicSLTP(symbol, side, price, atr)
-					log.Printf("🟢 TRADE request (custom lot, dynamic): %s %s price=%.2f lots=%.2f sl=%.2f tp=%.2f atr=%.2f strat=%s", symbol, side, price, lots, sl, tp, atr, strategy)
-				} else {
-					// ATR invalid, fallback ke fixed
-					sl, tp = calculateSLTP(symbol, side, price)
-					log.Printf("🟢 TRADE request (custom lot, fixed, invalid ATR): %s %s price=%.2f lots=%.2f sl=%.2f tp=%.2f strat=%s", symbol, side, price, lots, sl, tp, strategy)
-				}
-			} else {
-				// Fallback ke fixed SL/TP
-				sl, tp = calculateSLTP(symbol, side, price)
-				log.Printf("🟢 TRADE request (custom lot, fixed): %s %s price=%.2f lots=%.2f sl=%.2f tp=%.2f strat=%s", symbol, side, price, lots, sl, tp, strategy)
-			}
-
-			trade := TradeCommand{
-				Action:   "open",
-				Symbol:   symbol,
-				Side:     side,
-				Lots:     lots,
-				Price:    price,
-				SL:       sl,
-				TP:       tp,
-				Strategy: strategy,
-			}
+	// Tombol trading butuh role trader (status dan /settings boleh semua)
+	if action != "status" && action != "set" && userRole(callback.From.ID, callback.Message.Chat.ID) < roleTrader {
+		answerCallbackQuery(callback.ID, "🔒 Trader role required")
+		log.Printf("🔒 Callback %s denied for user %d", action, callback.From.ID)
+		return
+	}
 
-			if err := sendTradeToMT4(trade); err != nil {
-				answerCallbackQuery(callback.ID, "❌ Failed")
-				log.Printf("❌ sendTradeToMT4 error: %v", err)
-			} else {
-				answerCallbackQuery(callback.ID, fmt.Sprintf("✅ %.1f lot sent!", lots))
-				log.Printf("✅ Trade command dispatched to MT4")
-				if err := removeInlineKeyboard(callback.Message.Chat.ID, callback.Message.MessageID); err != nil {
-					log.Printf("⚠️ removeInlineKeyboard error: %v", err)
-				}
-			}
-			enqueueTrade(trade)
+	switch action {
+	case "trade", "lot", "risk":
+		if refuseIfEAOffline(callback) {
+			return
 		}
+		handleOpenCallback(callback, action, parts)
 
 	case "close":
 		if len(parts) >= 3 {
@@ -767,20 +1056,16 @@ func handleCallbackQuery(callback *TelegramCallbackQuery) {
 				log.Printf("❌ sendCloseToMT4 error: %v", err)
 			} else {
 				answerCallbackQuery(callback.ID, "✅ Close sent!")
-				sendTelegram(fmt.Sprintf("🔴 Close order #%.0f", ticket))
 				log.Printf("✅ Close command dispatched to MT4")
-				if err := removeInlineKeyboard(callback.Message.Chat.ID, callback.Message.MessageID); err != nil {
-					log.Printf("⚠️ removeInlineKeyboard error: %v", err)
-				}
+				markCallbackOutcome(callback, fmt.Sprintf("🔴 Close #%.0f sent by %s", ticket, userLabel(callback)))
 			}
-			enqueueClose(int(ticket), symbol, actualStrategy)
+			closeCmd := enqueueClose(int(ticket), symbol, actualStrategy)
+			journalCallback(callback, signalExecuted, 0, &closeCmd)
 		}
 
 	case "status":
 		// Enqueue a status command for EA to publish active orders
-		queueMu.Lock()
-		commandQueue = append(commandQueue, TradeCommand{Action: "status"})
-		queueMu.Unlock()
+		enqueueStatus()
 		answerCallbackQuery(callback.ID, "📋 Fetching active orders...")
 		if err := removeInlineKeyboard(callback.Message.Chat.ID, callback.Message.MessageID); err != nil {
 			log.Printf("⚠️ removeInlineKeyboard error: %v", err)
@@ -788,46 +1073,110 @@ func handleCallbackQuery(callback *TelegramCallbackQuery) {
 	case "ignore":
 		answerCallbackQuery(callback.ID, "Signal ignored")
 		log.Printf("🚫 Signal ignored by user")
-		if err := removeInlineKeyboard(callback.Message.Chat.ID, callback.Message.MessageID); err != nil {
-			log.Printf("⚠️ removeInlineKeyboard error: %v", err)
-		}
+		journalCallback(callback, signalIgnored, 0, nil)
+		markCallbackOutcome(callback, "🚫 Ignored by "+userLabel(callback))
 
 	case "keep":
 		answerCallbackQuery(callback.ID, "Order will remain open")
 		log.Printf("⏳ Keep order open selected by user")
-		if err := removeInlineKeyboard(callback.Message.Chat.ID, callback.Message.MessageID); err != nil {
-			log.Printf("⚠️ removeInlineKeyboard error: %v", err)
+		journalCallback(callback, signalKept, 0, nil)
+		markCallbackOutcome(callback, "⏳ Kept open by "+userLabel(callback))
+
+	case "cancel":
+		if len(parts) >= 2 {
+			handleAutoExecuteCancel(callback, parts[1])
+		}
+
+	case "set":
+		handleSettingsCallback(callback, parts)
+
+	case "custom":
+		if refuseIfEAOffline(callback) {
+			return
+		}
+		handleCustomLotCallback(callback, parts)
+
+	case "tconfirm", "tcancel":
+		if len(parts) >= 2 {
+			handleTradeConfirmCallback(callback, action, parts[1])
 		}
+
+	case "bulk", "bulkok", "bulkno":
+		handleBulkCloseCallback(callback, action, parts)
 	}
 }
 
-func healthHandler(w http.ResponseWriter, r *http.Request) {
-	status := map[string]interface{}{
-		"status":    "OK",
-		"timestamp": time.Now().Unix(),
-		"mt4_path":  config.MT4DataPath,
-		"telegram":  config.TelegramChatID != "",
-		"version":   "2.0.0",
+// handleOpenCallback - Tombol open: trade|symbol|side|price|strategy[|atr] (0.1 lot),
+// lot|lots|... dan risk|pct|... (lot dari risiko % balance). SL/TP mengikuti preferensi user.
+func handleOpenCallback(callback *TelegramCallbackQuery, action string, parts []string) {
+	value := ""
+	if action != "trade" {
+		if len(parts) < 2 {
+			return
+		}
+		value = parts[1]
+		parts = append([]string{action}, parts[2:]...)
+	}
+	if len(parts) < 5 {
+		return
+	}
+
+	symbol, side, strategy := parts[1], parts[2], parts[4]
+	price, _ := strconv.ParseFloat(parts[3], 64)
+	var atr float64
+	if len(parts) >= 6 {
+		atr, _ = strconv.ParseFloat(parts[5], 64)
 	}
+	prefs := getUserPrefs(callback.From.ID)
+	atr = prefs.tradeATR(atr)
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(status)
-}
+	lots := 0.1
+	switch action {
+	case "lot":
+		lots, _ = strconv.ParseFloat(value, 64)
+	case "risk":
+		var err error
+		lots, err = parseCustomLot(value+"%", customLotPrompt{Symbol: symbol, Side: side, Price: price, Strategy: strategy, ATR: atr})
+		if err != nil {
+			answerCallbackQuery(callback.ID, "❌ "+err.Error())
+			return
+		}
+	}
 
-// ============ HTTP BRIDGE: COMMANDS QUEUE ==========
-func commandsHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		w.WriteHeader(http.StatusMethodNotAllowed)
+	trade := buildOpenTrade(symbol, side, price, strategy, lots, atr)
+	trade.ID = newCommandID()
+	log.Printf("🟢 TRADE request (%s %s, sltp=%s): %s %s price=%.2f lots=%.2f sl=%.2f tp=%.2f atr=%.2f strat=%s",
+		action, value, prefs.SLTPPolicy, symbol, side, price, lots, trade.SL, trade.TP, atr, strategy)
+
+	if requestTradeConfirmation(callback, trade) {
 		return
 	}
+	dispatchManualOpen(callback, trade, callback.Message.Text)
+}
 
+// authorizeAPIRequest - Token via ?token= atau header X-API-Token; tulis 401 jika salah
+func authorizeAPIRequest(w http.ResponseWriter, r *http.Request) bool {
 	token := r.URL.Query().Get("token")
 	if token == "" {
 		token = r.Header.Get("X-API-Token")
 	}
 	if token != config.APIAuthToken {
+		metricAuthRejected.Inc(r.URL.Path)
 		w.WriteHeader(http.StatusUnauthorized)
 		w.Write([]byte("unauthorized"))
+		return false
+	}
+	return true
+}
+
+// ============ HTTP BRIDGE: COMMANDS QUEUE ==========
+func commandsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !authorizeAPIRequest(w, r) {
 		return
 	}
 
@@ -837,6 +1186,11 @@ func commandsHandler(w http.ResponseWriter, r *http.Request) {
 	commandQueue = commandQueue[:0]
 	queueMu.Unlock()
 
+	recordTerminalPoll(r.URL.Query().Get("account"))
+	recordCommandsDelivered(cmds)
+	markAutoExecutePickedUp(cmds)
+	journal.MarkCommandsDelivered(cmds)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"ok":       true,
@@ -854,10 +1208,13 @@ func validateConfig() error {
 	if config.TelegramChatID == "" {
 		return fmt.Errorf("TELEGRAM_CHAT_ID required")
 	}
+	if _, err := loadTimezone(config.DisplayTimezone); err != nil {
+		return fmt.Errorf("DISPLAY_TIMEZONE: %v", err)
+	}
 
 	// Test Telegram connection
 	url := fmt.Sprintf("https://api.telegram.org/bot%s/getMe", config.TelegramBotToken)
-	resp, err := http.Get(url)
+	resp, err := telegramClient.Get(url)
 	if err != nil {
 		return fmt.Errorf("failed to connect to Telegram: %v", err)
 	}
@@ -893,6 +1250,73 @@ func main() {
 		log.Fatalf("❌ Configuration error: %v", err)
 	}
 
+	// Load trading schedule (sessions, holidays, news blackouts)
+	if err := loadSchedule(); err != nil {
+		log.Fatalf("❌ Schedule: %v", err)
+	}
+
+	// Lot step custom lot (global dan per symbol)
+	if err := validateLotSteps(); err != nil {
+		log.Fatalf("❌ Lot limits: %v", err)
+	}
+
+	// Jadwal laporan harian / mingguan
+	reports, err := loadReportSchedule()
+	if err != nil {
+		log.Fatalf("❌ Report schedule: %v", err)
+	}
+
+	// Notification routes (per message type / strategy / symbol)
+	routes, err := parseNotifyRoutes(config.NotifyRoutes)
+	if err != nil {
+		log.Fatalf("❌ NOTIFY_ROUTES: %v", err)
+	}
+	notifyRoutes = routes
+	log.Printf("🧭 Notification routes: %s", formatRoutes())
+
+	// TradingView alert mapping
+	if err := loadTVMapping(); err != nil {
+		log.Fatalf("❌ TV_MAPPING_FILE: %v", err)
+	}
+
+	// Signal source tambahan (partner HTTP, feed JSON lines)
+	if err := loadSignalSources(); err != nil {
+		log.Fatalf("❌ SIGNAL_SOURCES_FILE: %v", err)
+	}
+
+	// Outbound event webhooks
+	endpoints, err := parseEventWebhooks(config.EventWebhooks)
+	if err != nil {
+		log.Fatalf("❌ EVENT_WEBHOOKS: %v", err)
+	}
+	eventEndpoints = endpoints
+
+	// Load message templates (embedded defaults + TEMPLATES_DIR overrides)
+	if err := loadTemplates(); err != nil {
+		log.Fatalf("❌ Template error: %v", err)
+	}
+	log.Printf("🧩 Message templates: %s (parse mode %q)", config.TemplatesDir, config.ParseMode)
+
+	// Open trade journal
+	if j, err := openJournal(config.JournalDBPath); err != nil {
+		log.Printf("⚠️  Journal disabled: %v", err)
+	} else {
+		journal = j
+		log.Printf("📒 Trade journal: %s", config.JournalDBPath)
+	}
+
+	// Restore commands queued before the last shutdown
+	if config.QueueStatePath != "off" {
+		if n, expired, err := restoreQueue(config.QueueStatePath); err != nil {
+			log.Printf("⚠️  Queue restore failed: %v", err)
+		} else {
+			if n > 0 {
+				log.Printf("💾 Queue restored: %d commands from %s", n, config.QueueStatePath)
+			}
+			notifyExpiredOpens(expired)
+		}
+	}
+
 	// Check MT4 connection
 	if err := checkMT4Connection(); err != nil {
 		log.Printf("⚠️  MT4 connection warning: %v", err)
@@ -907,24 +1331,44 @@ func main() {
 
 	// Setup HTTP routes
 	mux := http.NewServeMux()
-	mux.HandleFunc("/signal", signalHandler)     // Receive signals from MT4
-	mux.HandleFunc("/webhook", webhookHandler)   // Telegram webhook
-	mux.HandleFunc("/health", healthHandler)     // Health check
-	mux.HandleFunc("/commands", commandsHandler) // HTTP bridge for remote EA
+	mux.HandleFunc("/signal", signalHandler)                   // Receive signals from MT4
+	mux.HandleFunc("/webhook", webhookHandler)                 // Telegram webhook
+	mux.HandleFunc("/health", healthHandler)                   // Detailed health report
+	mux.HandleFunc("/health/live", livenessHandler)            // Liveness probe
+	mux.HandleFunc("/health/ready", readinessHandler)          // Readiness probe
+	mux.HandleFunc("/commands", commandsHandler)               // HTTP bridge for remote EA
+	mux.HandleFunc("/journal", journalHandler)                 // Trade journal (signal lifecycle)
+	mux.HandleFunc("/stats", statsHandler)                     // Strategy performance (JSON)
+	mux.HandleFunc("/export", exportHandler)                   // CSV/JSON export of journal data
+	mux.HandleFunc("/close-all", closeAllHandler)              // Emergency bulk close
+	mux.HandleFunc("/metrics", metricsHandler)                 // Prometheus metrics
+	mux.HandleFunc("/tv", tvHandler)                           // TradingView alert webhooks
+	mux.HandleFunc("/sources/", sourcesHandler)                // Generic HTTP signal sources
+	mux.HandleFunc("/events/dead-letters", deadLettersHandler) // Failed outbound events
+	mux.HandleFunc("/events/replay", eventsReplayHandler)      // Re-send dead-lettered events
 
 	// Start server
 	log.Printf("🌐 Server starting on %s", config.Port)
 	log.Printf("📱 Send test message to verify Telegram...")
 
+	go startTelegramOutbox()
+	startEventWebhooks()
+	startSignalSources()
+	registerBotCommands()
+	disabledStrategies = getEnvSet("DISABLED_STRATEGIES")
+
 	// Send startup notification
 	startupMsg := fmt.Sprintf("🚀 Trading System Online\n🕐 %s\n💻 Ready for signals!",
 		time.Now().Format("2006-01-02 15:04:05"))
-	sendTelegram(startupMsg)
+	notify(msgSystem, startupMsg)
+
+	go startReportScheduler(reports)
+	go startHeartbeatMonitor()
+	go startSignalExpiry()
+	go startTemplateWatcher()
 
 	log.Printf("🎯 Waiting for MT4 signals...")
 	log.Printf("🛑 Press Ctrl+C to stop")
 
-	if err := http.ListenAndServe(config.Port, mux); err != nil {
-		log.Fatalf("❌ Server failed: %v", err)
-	}
+	runServer(&http.Server{Addr: config.Port, Handler: mux})
 }
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============ METRICS ============
+// Counter dan histogram minimal dengan format teks Prometheus (tanpa dependency).
+
+type counterVec struct {
+	mu     sync.Mutex
+	name   string
+	help   string
+	labels []string
+	values map[string]float64
+}
+
+type histogram struct {
+	labelValues []string
+	counts      []uint64
+	sum         float64
+	count       uint64
+}
+
+type histogramVec struct {
+	mu      sync.Mutex
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+	series  map[string]*histogram
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, values: map[string]float64{}}
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labels: labels, buckets: buckets, series: map[string]*histogram{}}
+}
+
+func (c *counterVec) Inc(labelValues ...string) {
+	c.mu.Lock()
+	c.values[strings.Join(labelValues, "\xff")]++
+	c.mu.Unlock()
+}
+
+func (h *histogramVec) Observe(v float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.series[key]
+	if s == nil {
+		s = &histogram{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, le := range h.buckets {
+		if v <= le {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+// labelEscaper - Escaping label value format teks Prometheus: hanya backslash, kutip dan newline
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatLabels(names, values []string, extra ...string) string {
+	var parts []string
+	for i, name := range names {
+		if i < len(values) {
+			parts = append(parts, name+`="`+escapeLabelValue(values[i])+`"`)
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		parts = append(parts, extra[i]+`="`+escapeLabelValue(extra[i+1])+`"`)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, strings.Split(key, "\xff")), formatFloat(c.values[key]))
+	}
+}
+
+func (h *histogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := h.series[key]
+		for i, le := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, s.labelValues, "le", formatFloat(le)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, s.labelValues), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, s.labelValues), s.count)
+	}
+}
+
+func writeGauge(w io.Writer, name, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", name, help, name, name, formatFloat(value))
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	metricSignals = newCounterVec("trading_signals_received_total",
+		"Signals received on /signal by strategy and type.", "strategy", "type")
+	metricTelegramLatency = newHistogramVec("trading_telegram_request_duration_seconds",
+		"Telegram Bot API request latency by method.", []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}, "method")
+	metricTelegramErrors = newCounterVec("trading_telegram_errors_total",
+		"Failed Telegram Bot API requests by method.", "method")
+	metricCommandDelivery = newHistogramVec("trading_command_delivery_seconds",
+		"Time from enqueue until the EA picked the command up via /commands.", []float64{1, 2, 5, 10, 30, 60, 120, 300, 600}, "action")
+	metricCallbacks = newCounterVec("trading_callback_actions_total",
+		"Telegram inline button callbacks by action.", "action")
+	metricNotifierErrors = newCounterVec("trading_notifier_errors_total",
+		"Failed Discord/Slack webhook deliveries by sink type.", "sink")
+	metricEventFailures = newCounterVec("trading_event_webhook_failures_total",
+		"Outbound events dead-lettered after all retries, by event type.", "event")
+	metricAuthRejected = newCounterVec("trading_auth_rejected_total",
+		"Requests rejected because of an invalid API token, by endpoint.", "endpoint")
+	metricSourceSignals = newCounterVec("trading_source_signals_total",
+		"Signals from TradingView and other signal sources by source and result.", "source", "result")
+)
+
+// signalType - Jenis payload /signal untuk label metrics
+func signalType(p SignalPayload) string {
+	switch {
+	case p.Strategy == "ORDER_OPENED_CONFIRMATION":
+		return "order_opened"
+	case p.Strategy == "ORDER_CLOSED_CONFIRMATION":
+		return "order_closed"
+	case p.Strategy == "ORDERS_STATUS":
+		return "orders_status"
+	case p.Strategy == "ACCOUNT_INFO":
+		return "account_info"
+	case strings.HasPrefix(p.Side, "CLOSE_"):
+		return "close"
+	default:
+		return "open"
+	}
+}
+
+func recordCommandsDelivered(cmds []TradeCommand) {
+	now := time.Now()
+	for _, cmd := range cmds {
+		if !cmd.enqueuedAt.IsZero() {
+			metricCommandDelivery.Observe(now.Sub(cmd.enqueuedAt).Seconds(), cmd.Action)
+		}
+	}
+}
+
+// ============ HTTP: METRICS ============
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+
+	metricSignals.write(&buf)
+	metricTelegramLatency.write(&buf)
+	metricTelegramErrors.write(&buf)
+	metricCommandDelivery.write(&buf)
+	metricCallbacks.write(&buf)
+	metricNotifierErrors.write(&buf)
+	metricEventFailures.write(&buf)
+	metricAuthRejected.write(&buf)
+	metricSourceSignals.write(&buf)
+
+	depth, oldest := queueStats()
+	writeGauge(&buf, "trading_command_queue_depth", "Commands waiting for the EA to poll /commands.", float64(depth))
+	writeGauge(&buf, "trading_command_queue_oldest_seconds", "Age of the oldest undelivered command.", oldest.Seconds())
+
+	writeGauge(&buf, "trading_telegram_outbox_depth", "Telegram messages waiting in the outbound queue.", float64(outboxDepth()))
+
+	polled := lastPollTime()
+	pollAge := -1.0 // belum pernah poll
+	if !polled.IsZero() {
+		pollAge = time.Since(polled).Seconds()
+	}
+	writeGauge(&buf, "trading_ea_poll_age_seconds", "Seconds since the EA last polled /commands (-1 = never).", pollAge)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(buf.Bytes())
+}
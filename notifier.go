@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============ NOTIFIERS ============
+// Semua notifikasi lewat interface notifier. Telegram memakai outbox (dengan tombol);
+// Discord dan Slack dikirim ke incoming webhook sebagai embed / blocks (read-only,
+// tombol tidak ikut). Tujuan dipilih per route di NOTIFY_ROUTES:
+//   -1001234567     chat Telegram
+//   discord:TEAM    URL dari DISCORD_WEBHOOK_TEAM
+//   slack:OPS       URL dari SLACK_WEBHOOK_OPS
+
+const (
+	webhookQueueSize  = 100
+	webhookMaxRetries = 3
+)
+
+// webhookBaseBackoff - Jeda retry pertama (berlipat dua tiap percobaan); var agar test bisa mempercepat
+var webhookBaseBackoff = 2 * time.Second
+
+// webhookClient - Timeout per request, supaya webhook yang menggantung tidak memblokir worker
+var webhookClient = &http.Client{Timeout: 15 * time.Second}
+
+// notification - Satu pesan untuk satu tujuan
+type notification struct {
+	Type     string // jenis pesan NOTIFY_ROUTES (open, close, confirmation, ...)
+	Strategy string
+	Symbol   string
+	Message  message
+	Buttons  *TelegramInlineKeyboard           // hanya Telegram
+	OnSent   func(*TelegramSentMessage, error) // hanya Telegram, boleh nil
+}
+
+type notifier interface {
+	Name() string
+	Notify(n notification) // async; error dicatat oleh notifier sendiri
+}
+
+// ============ TELEGRAM NOTIFIER ============
+
+type telegramNotifier struct {
+	ChatID string
+}
+
+func (t telegramNotifier) Name() string { return t.ChatID }
+
+// Notify - Pesan template memakai TELEGRAM_PARSE_MODE, teks biasa dikirim apa adanya
+func (t telegramNotifier) Notify(n notification) {
+	if n.Message.Template == "" {
+		queueFormattedMessage(t.ChatID, n.Message.Text, "", n.Buttons, n.OnSent)
+		return
+	}
+	queueFormattedMessage(t.ChatID, n.Message.render(config.ParseMode), config.ParseMode, n.Buttons, n.OnSent)
+}
+
+// defaultNotifier - TELEGRAM_CHAT_ID (notifikasi sistem, fallback routing)
+func defaultNotifier() notifier {
+	return telegramNotifier{ChatID: config.TelegramChatID}
+}
+
+// ============ WEBHOOK NOTIFIERS (DISCORD / SLACK) ============
+
+const (
+	sinkDiscord = "discord"
+	sinkSlack   = "slack"
+)
+
+// Warna embed Discord per jenis pesan
+var discordColors = map[string]int{
+	msgOpen:         0x3498DB,
+	msgClose:        0xE67E22,
+	msgConfirmation: 0x2ECC71,
+	msgReport:       0x9B59B6,
+	msgError:        0xE74C3C,
+}
+
+// webhookNotifier - Incoming webhook Discord / Slack dengan antrian dan worker sendiri
+type webhookNotifier struct {
+	Kind  string // discord | slack
+	Label string
+	URL   string
+	queue chan notification
+}
+
+var (
+	webhookNotifiersMu sync.Mutex
+	webhookNotifiers   = map[string]*webhookNotifier{}
+)
+
+// getWebhookNotifier - discord:NAME / slack:NAME → notifier (satu worker per webhook)
+func getWebhookNotifier(kind, name string) (*webhookNotifier, error) {
+	envKey := strings.ToUpper(kind) + "_WEBHOOK_" + strings.ToUpper(name)
+	url := getEnv(envKey, "")
+	if url == "" {
+		return nil, fmt.Errorf("%s not set", envKey)
+	}
+
+	webhookNotifiersMu.Lock()
+	defer webhookNotifiersMu.Unlock()
+	key := kind + ":" + strings.ToUpper(name)
+	if w, ok := webhookNotifiers[key]; ok {
+		return w, nil
+	}
+	w := &webhookNotifier{Kind: kind, Label: key, URL: url, queue: make(chan notification, webhookQueueSize)}
+	webhookNotifiers[key] = w
+	go w.run()
+	return w, nil
+}
+
+func (w *webhookNotifier) Name() string { return w.Label }
+
+func (w *webhookNotifier) Notify(n notification) {
+	select {
+	case w.queue <- n:
+	default:
+		log.Printf("⚠️ %s queue full, notification dropped: %s", w.Label, n.Type)
+		metricNotifierErrors.Inc(w.Kind)
+	}
+}
+
+func (w *webhookNotifier) run() {
+	for n := range w.queue {
+		if err := w.deliver(n); err != nil {
+			log.Printf("❌ %s delivery failed: %v", w.Label, err)
+			metricNotifierErrors.Inc(w.Kind)
+		}
+	}
+}
+
+// deliver - POST dengan retry untuk network error, 429 (Retry-After) dan 5xx
+func (w *webhookNotifier) deliver(n notification) error {
+	body, _ := json.Marshal(w.payload(n))
+	backoff := webhookBaseBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := webhookClient.Post(w.URL, "application/json", bytes.NewReader(body))
+		wait := backoff
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+				return nil
+			}
+			err = fmt.Errorf("%s", resp.Status)
+			if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+				return err // 4xx lain: payload / URL salah, tidak di-retry
+			}
+			if secs, convErr := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); convErr == nil && secs > 0 {
+				wait = time.Duration(secs * float64(time.Second))
+			}
+		}
+		if attempt >= webhookMaxRetries {
+			return fmt.Errorf("after %d attempts: %v", attempt+1, err)
+		}
+		log.Printf("⏳ %s send failed (attempt %d): %v — retry in %s", w.Label, attempt+1, err, wait)
+		time.Sleep(wait)
+		backoff *= 2
+	}
+}
+
+// splitTitle - Baris pertama jadi judul, sisanya isi (jumlah baris sama di semua parse mode)
+func splitTitle(plain, formatted string) (string, string) {
+	title, _, _ := strings.Cut(plain, "\n")
+	_, body, _ := strings.Cut(formatted, "\n")
+	return title, body
+}
+
+func truncate(s string, max int) string {
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return s
+}
+
+// payload - Embed Discord atau blocks Slack
+func (w *webhookNotifier) payload(n notification) interface{} {
+	plain := n.Message.render("")
+	if w.Kind == sinkSlack {
+		title, body := splitTitle(plain, n.Message.render(parseModeSlack))
+		blocks := []map[string]interface{}{
+			{"type": "header", "text": map[string]interface{}{"type": "plain_text", "text": truncate(title, 150), "emoji": true}},
+		}
+		if body != "" {
+			blocks = append(blocks, map[string]interface{}{
+				"type": "section", "text": map[string]interface{}{"type": "mrkdwn", "text": truncate(body, 3000)},
+			})
+		}
+		return map[string]interface{}{"text": truncate(plain, 3000), "blocks": blocks}
+	}
+
+	title, body := splitTitle(plain, n.Message.render(parseModeDiscord))
+	embed := map[string]interface{}{
+		"title":     truncate(title, 256),
+		"timestamp": time.Now().UTC().Format(time.RFC3339),
+	}
+	if body != "" {
+		embed["description"] = truncate(body, 4096)
+	}
+	if color, ok := discordColors[n.Type]; ok {
+		embed["color"] = color
+	}
+	return map[string]interface{}{"embeds": []interface{}{embed}}
+}
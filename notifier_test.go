@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// webhookStub - Server httptest yang menjawab status dari daftar (status terakhir diulang)
+func webhookStub(t *testing.T, statuses []int, header http.Header) (*httptest.Server, *int32, chan []byte) {
+	t.Helper()
+	var calls int32
+	bodies := make(chan []byte, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(statuses[min(n, len(statuses))-1])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, bodies
+}
+
+func fastWebhookBackoff(t *testing.T) {
+	t.Helper()
+	old := webhookBaseBackoff
+	webhookBaseBackoff = time.Millisecond
+	t.Cleanup(func() { webhookBaseBackoff = old })
+}
+
+func TestWebhookDiscordPayload(t *testing.T) {
+	srv, _, bodies := webhookStub(t, []int{http.StatusNoContent}, nil)
+	w := &webhookNotifier{Kind: sinkDiscord, Label: "discord:TEST", URL: srv.URL}
+
+	err := w.deliver(notification{Type: msgError, Message: plainMessage("📴 EA OFFLINE\nAccount *5012345*")})
+	if err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+
+	var payload struct {
+		Embeds []struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Color       int    `json:"color"`
+			Timestamp   string `json:"timestamp"`
+		} `json:"embeds"`
+	}
+	if err := json.Unmarshal(<-bodies, &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if len(payload.Embeds) != 1 {
+		t.Fatalf("embeds = %d, want 1", len(payload.Embeds))
+	}
+	embed := payload.Embeds[0]
+	if embed.Title != "📴 EA OFFLINE" {
+		t.Errorf("title = %q", embed.Title)
+	}
+	if embed.Description != `Account \*5012345\*` {
+		t.Errorf("description = %q", embed.Description)
+	}
+	if embed.Color != discordColors[msgError] {
+		t.Errorf("color = %#x, want %#x", embed.Color, discordColors[msgError])
+	}
+	if _, err := time.Parse(time.RFC3339, embed.Timestamp); err != nil {
+		t.Errorf("timestamp %q: %v", embed.Timestamp, err)
+	}
+}
+
+func TestWebhookSlackPayload(t *testing.T) {
+	srv, _, bodies := webhookStub(t, []int{http.StatusOK}, nil)
+	w := &webhookNotifier{Kind: sinkSlack, Label: "slack:TEST", URL: srv.URL}
+
+	text := "📒 Daily Report\n💵 Realized P&L: +12.50 <USD>"
+	if err := w.deliver(notification{Type: msgReport, Message: plainMessage(text)}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+
+	var payload struct {
+		Text   string `json:"text"`
+		Blocks []struct {
+			Type string `json:"type"`
+			Text struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"text"`
+		} `json:"blocks"`
+	}
+	if err := json.Unmarshal(<-bodies, &payload); err != nil {
+		t.Fatalf("payload: %v", err)
+	}
+	if payload.Text != text {
+		t.Errorf("fallback text = %q, want %q", payload.Text, text)
+	}
+	if len(payload.Blocks) != 2 {
+		t.Fatalf("blocks = %d, want 2", len(payload.Blocks))
+	}
+	if b := payload.Blocks[0]; b.Type != "header" || b.Text.Type != "plain_text" || b.Text.Text != "📒 Daily Report" {
+		t.Errorf("header block = %+v", b)
+	}
+	if b := payload.Blocks[1]; b.Type != "section" || b.Text.Type != "mrkdwn" || !strings.Contains(b.Text.Text, "&lt;USD&gt;") {
+		t.Errorf("section block = %+v", b)
+	}
+}
+
+func TestWebhookRetriesServerErrors(t *testing.T) {
+	fastWebhookBackoff(t)
+	srv, calls, _ := webhookStub(t, []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusNoContent}, nil)
+	w := &webhookNotifier{Kind: sinkDiscord, Label: "discord:TEST", URL: srv.URL}
+
+	if err := w.deliver(notification{Type: msgSystem, Message: plainMessage("🚀 started")}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if got := atomic.LoadInt32(calls); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+func TestWebhookGivesUpAfterMaxRetries(t *testing.T) {
+	fastWebhookBackoff(t)
+	srv, calls, _ := webhookStub(t, []int{http.StatusServiceUnavailable}, nil)
+	w := &webhookNotifier{Kind: sinkSlack, Label: "slack:TEST", URL: srv.URL}
+
+	if err := w.deliver(notification{Type: msgSystem, Message: plainMessage("🚀 started")}); err == nil {
+		t.Fatal("deliver: want error after retries")
+	}
+	if got := atomic.LoadInt32(calls); got != webhookMaxRetries+1 {
+		t.Errorf("attempts = %d, want %d", got, webhookMaxRetries+1)
+	}
+}
+
+func TestWebhookHonoursRetryAfter(t *testing.T) {
+	fastWebhookBackoff(t)
+	srv, calls, _ := webhookStub(t, []int{http.StatusTooManyRequests, http.StatusNoContent}, http.Header{"Retry-After": {"0.3"}})
+	w := &webhookNotifier{Kind: sinkDiscord, Label: "discord:TEST", URL: srv.URL}
+
+	start := time.Now()
+	if err := w.deliver(notification{Type: msgSystem, Message: plainMessage("🚀 started")}); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("retried after %s, want >= Retry-After 300ms", elapsed)
+	}
+	if got := atomic.LoadInt32(calls); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
+
+func TestWebhookNoRetryOnClientError(t *testing.T) {
+	fastWebhookBackoff(t)
+	srv, calls, _ := webhookStub(t, []int{http.StatusBadRequest}, nil)
+	w := &webhookNotifier{Kind: sinkDiscord, Label: "discord:TEST", URL: srv.URL}
+
+	if err := w.deliver(notification{Type: msgSystem, Message: plainMessage("🚀 started")}); err == nil {
+		t.Fatal("deliver: want error for 400")
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
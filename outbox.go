@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+// ============ TELEGRAM OUTBOX ============
+// Semua sendMessage lewat satu antrian FIFO: dikirim berurutan, dibatasi
+// TELEGRAM_CHAT_INTERVAL_MS per chat, dan di-retry dengan exponential backoff.
+// 429 memakai retry_after dari Telegram; 4xx lain dianggap permanen (tidak di-retry).
+
+const (
+	outboxBaseBackoff = 1 * time.Second
+	outboxMaxBackoff  = 60 * time.Second
+)
+
+// telegramAPIError - Error dari Bot API (status non-2xx)
+type telegramAPIError struct {
+	StatusCode  int
+	Description string
+	RetryAfter  int
+}
+
+func (e *telegramAPIError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("telegram %d: %s", e.StatusCode, e.Description)
+	}
+	return fmt.Sprintf("telegram status %d", e.StatusCode)
+}
+
+// retryable - Network error, 429 dan 5xx boleh di-retry
+func (e *telegramAPIError) retryable() bool {
+	return e.StatusCode == 429 || e.StatusCode >= 500
+}
+
+// parseTelegramError - Baca body error {"error_code":..,"description":..,"parameters":{"retry_after":..}}
+func parseTelegramError(statusCode int, body io.Reader) *telegramAPIError {
+	var result struct {
+		Description string `json:"description"`
+		Parameters  struct {
+			RetryAfter int `json:"retry_after"`
+		} `json:"parameters"`
+	}
+	json.NewDecoder(body).Decode(&result)
+	return &telegramAPIError{StatusCode: statusCode, Description: result.Description, RetryAfter: result.Parameters.RetryAfter}
+}
+
+type outboundMessage struct {
+	ChatID    string
+	Text      string
+	ParseMode string
+	Buttons   *TelegramInlineKeyboard
+	OnSent    func(*TelegramSentMessage, error) // dipanggil sekali: sukses atau gagal permanen
+	queuedAt  time.Time
+}
+
+var (
+	outboxMu     sync.Mutex
+	outbox       []*outboundMessage
+	outboxWake   = make(chan struct{}, 1)
+	outboxIdle   = sync.NewCond(&outboxMu)
+	outboxBusy   bool
+	lastChatSend = map[string]time.Time{}
+)
+
+// queueFormattedMessage - Masukkan pesan untuk chat tujuan ke outbox; parseMode kosong = teks biasa, onSent boleh nil
+func queueFormattedMessage(chatID, text, parseMode string, buttons *TelegramInlineKeyboard, onSent func(*TelegramSentMessage, error)) {
+	outboxMu.Lock()
+	outbox = append(outbox, &outboundMessage{ChatID: chatID, Text: text, ParseMode: parseMode, Buttons: buttons, OnSent: onSent, queuedAt: time.Now()})
+	outboxMu.Unlock()
+
+	select {
+	case outboxWake <- struct{}{}:
+	default:
+	}
+}
+
+// outboxDepth - Jumlah pesan yang belum terkirim (termasuk yang sedang dikirim)
+func outboxDepth() int {
+	outboxMu.Lock()
+	defer outboxMu.Unlock()
+	n := len(outbox)
+	if outboxBusy {
+		n++
+	}
+	return n
+}
+
+// startTelegramOutbox - Worker tunggal agar urutan pesan terjaga
+func startTelegramOutbox() {
+	for {
+		outboxMu.Lock()
+		if len(outbox) == 0 {
+			outboxBusy = false
+			outboxIdle.Broadcast()
+			outboxMu.Unlock()
+			<-outboxWake
+			continue
+		}
+		msg := outbox[0]
+		outbox = outbox[1:]
+		outboxBusy = true
+		outboxMu.Unlock()
+
+		sent, err := deliverWithRetry(msg)
+		if msg.OnSent != nil {
+			msg.OnSent(sent, err)
+		}
+	}
+}
+
+// deliverWithRetry - Kirim satu pesan sampai sukses, error permanen, atau TELEGRAM_MAX_RETRIES habis
+func deliverWithRetry(msg *outboundMessage) (*TelegramSentMessage, error) {
+	backoff := outboxBaseBackoff
+	for attempt := 0; ; attempt++ {
+		waitChatInterval(msg.ChatID)
+
+		sent, err := sendTelegramMessage(msg.ChatID, msg.Text, msg.ParseMode, msg.Buttons)
+		if err == nil {
+			if attempt > 0 {
+				log.Printf("📨 Telegram delivered after %d retries (queued %s ago)", attempt, time.Since(msg.queuedAt).Round(time.Second))
+			}
+			return sent, nil
+		}
+
+		wait := backoff
+		if apiErr, ok := err.(*telegramAPIError); ok {
+			if !apiErr.retryable() {
+				log.Printf("❌ Telegram rejected message (not retried): %v", err)
+				return nil, err
+			}
+			if apiErr.RetryAfter > 0 {
+				wait = time.Duration(apiErr.RetryAfter) * time.Second
+			}
+		}
+		if attempt >= config.TelegramMaxRetries {
+			log.Printf("❌ Telegram send failed after %d attempts: %v", attempt+1, err)
+			return nil, err
+		}
+
+		log.Printf("⏳ Telegram send failed (attempt %d): %v — retry in %s", attempt+1, err, wait)
+		time.Sleep(wait)
+		if backoff *= 2; backoff > outboxMaxBackoff {
+			backoff = outboxMaxBackoff
+		}
+	}
+}
+
+// waitChatInterval - Jeda minimum antar pesan ke chat yang sama
+func waitChatInterval(chatID string) {
+	interval := time.Duration(config.TelegramChatIntervalMs) * time.Millisecond
+	if last, ok := lastChatSend[chatID]; ok {
+		if wait := interval - time.Since(last); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	lastChatSend[chatID] = time.Now()
+}
+
+// flushTelegramOutbox - Tunggu outbox kosong (dipakai saat shutdown); false jika timeout
+func flushTelegramOutbox(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		outboxMu.Lock()
+		for len(outbox) > 0 || outboxBusy {
+			outboxIdle.Wait()
+		}
+		outboxMu.Unlock()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============ USER PREFERENCES ============
+// Preferensi per user Telegram (disimpan di journal, tabel user_prefs), diubah lewat /settings:
+// preset lot, risiko % default, kebijakan SL/TP, verbosity notifikasi, timezone dan bahasa.
+// Keyboard signal memakai preferensi pemilik chat (hanya jika TELEGRAM_CHAT_ID adalah DM;
+// keyboard grup dipakai bersama sehingga memakai default), sedangkan eksekusi tombol dan
+// prompt ✏️ Custom memakai preferensi user yang menekan.
+
+const (
+	sltpATR   = "atr"   // SL/TP dinamis dari ATR, fallback fixed
+	sltpFixed = "fixed" // selalu SL/TP fixed
+
+	verbosityAll        = "all"        // semua notifikasi
+	verbosityActionable = "actionable" // signal tanpa tombol (blackout / EA offline) tidak dikirim
+
+	maxLotPresets = 8
+)
+
+type UserPrefs struct {
+	LotPresets []float64 `json:"lot_presets"`
+	RiskPct    float64   `json:"risk_pct"`
+	SLTPPolicy string    `json:"sltp_policy"`
+	Verbosity  string    `json:"verbosity"`
+	Timezone   string    `json:"timezone"`
+	Language   string    `json:"language"`
+}
+
+var prefsMu sync.Mutex
+var prefsCache = map[int64]UserPrefs{}
+
+func defaultPrefs() UserPrefs {
+	return UserPrefs{
+		LotPresets: append([]float64(nil), config.LotPresets...),
+		RiskPct:    config.DefaultRiskPct,
+		SLTPPolicy: sltpATR,
+		Verbosity:  verbosityAll,
+		Timezone:   config.DisplayTimezone,
+		Language:   config.Language,
+	}
+}
+
+// withDefaults - Isi field kosong (mis. prefs lama sebelum field baru ditambahkan)
+func (p UserPrefs) withDefaults() UserPrefs {
+	d := defaultPrefs()
+	if len(p.LotPresets) == 0 {
+		p.LotPresets = d.LotPresets
+	}
+	if p.SLTPPolicy == "" {
+		p.SLTPPolicy = d.SLTPPolicy
+	}
+	if p.Verbosity == "" {
+		p.Verbosity = d.Verbosity
+	}
+	if p.Timezone == "" {
+		p.Timezone = d.Timezone
+	}
+	if normalizeLanguage(p.Language) == "" {
+		p.Language = d.Language
+	}
+	return p
+}
+
+// loadTimezone - Nama IANA wajib ("" dan "Local" ditolak: hasilnya tergantung server)
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" || name == "Local" {
+		return nil, fmt.Errorf("invalid timezone %q (use an IANA name such as Asia/Jakarta)", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %v", name, err)
+	}
+	return loc, nil
+}
+
+// location - Timezone user; prefs lama yang tidak valid lagi memakai DISPLAY_TIMEZONE (divalidasi saat start)
+func (p UserPrefs) location() *time.Location {
+	if loc, err := loadTimezone(p.Timezone); err == nil {
+		return loc
+	}
+	if loc, err := loadTimezone(config.DisplayTimezone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// tradeATR - ATR yang dipakai buildOpenTrade sesuai kebijakan SL/TP (0 = fixed)
+func (p UserPrefs) tradeATR(atr float64) float64 {
+	if p.SLTPPolicy == sltpFixed {
+		return 0
+	}
+	return atr
+}
+
+func getUserPrefs(userID int64) UserPrefs {
+	prefsMu.Lock()
+	defer prefsMu.Unlock()
+	if p, ok := prefsCache[userID]; ok {
+		return p
+	}
+
+	p := defaultPrefs()
+	if raw, ok := journal.UserPrefs(userID); ok {
+		var saved UserPrefs
+		if err := json.Unmarshal([]byte(raw), &saved); err != nil {
+			log.Printf("⚠️ Invalid prefs for user %d: %v", userID, err)
+		} else {
+			p = saved.withDefaults()
+		}
+	}
+	prefsCache[userID] = p
+	return p
+}
+
+func saveUserPrefs(userID int64, p UserPrefs) {
+	prefsMu.Lock()
+	prefsCache[userID] = p
+	prefsMu.Unlock()
+
+	b, _ := json.Marshal(p)
+	journal.SaveUserPrefs(userID, string(b))
+}
+
+// chatPrefs - Preferensi untuk pesan ke TELEGRAM_CHAT_ID: DM (ID positif) = prefs user tsb, grup = default
+func chatPrefs() UserPrefs {
+	if id, err := strconv.ParseInt(config.TelegramChatID, 10, 64); err == nil && id > 0 {
+		return getUserPrefs(id)
+	}
+	return defaultPrefs()
+}
+
+// ownsSignalChat - true jika TELEGRAM_CHAT_ID adalah DM dengan user ini (prefs-nya dipakai chatPrefs)
+func ownsSignalChat(userID int64) bool {
+	return config.TelegramChatID == strconv.FormatInt(userID, 10)
+}
+
+// parseLotList - "0.1, 0.2 0.5" → [0.1 0.2 0.5] (unik, urut, sesuai LOT_STEP / MIN_LOT / MAX_LOT)
+func parseLotList(s string) ([]float64, error) {
+	seen := map[float64]bool{}
+	var lots []float64
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == ';' }) {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("invalid lot %q", field)
+		}
+		if steps := v / config.LotStep; math.Abs(steps-math.Round(steps)) > 1e-6 {
+			return nil, fmt.Errorf("lot %g is not a multiple of lot step %g", v, config.LotStep)
+		}
+		if v < config.MinLot || v > config.MaxLot {
+			return nil, fmt.Errorf("lot %g outside %g-%g", v, config.MinLot, config.MaxLot)
+		}
+		if !seen[v] {
+			seen[v] = true
+			lots = append(lots, v)
+		}
+	}
+	if len(lots) == 0 || len(lots) > maxLotPresets {
+		return nil, fmt.Errorf("enter 1-%d lot sizes", maxLotPresets)
+	}
+	sort.Float64s(lots)
+	return lots, nil
+}
+
+// lotButtonRows - Tombol lot (3 per baris) dari preset, lalu risiko % dan ✏️ Custom
+func lotButtonRows(signalData string, prefs UserPrefs) [][]TelegramInlineButton {
+	var rows [][]TelegramInlineButton
+	var row []TelegramInlineButton
+	for _, lots := range prefs.LotPresets {
+		label := strconv.FormatFloat(lots, 'f', -1, 64)
+		if !strings.Contains(label, ".") {
+			label += ".0"
+		}
+		row = append(row, TelegramInlineButton{Text: "📊 " + label + " LOT", CallbackData: "lot|" + label + "|" + signalData})
+		if len(row) == 3 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+
+	if prefs.RiskPct > 0 {
+		pct := strconv.FormatFloat(prefs.RiskPct, 'f', -1, 64)
+		row = append(row, TelegramInlineButton{Text: "🎯 " + pct + "% RISK", CallbackData: "risk|" + pct + "|" + signalData})
+	}
+	row = append(row, TelegramInlineButton{Text: "✏️ Custom", CallbackData: "custom|" + signalData})
+	return append(rows, row)
+}
+
+// ============ TELEGRAM: /settings ============
+
+// formatLotList - [0.1 0.25] → "0.1 0.25"
+func formatLotList(lots []float64) string {
+	labels := make([]string, len(lots))
+	for i, l := range lots {
+		labels[i] = strconv.FormatFloat(l, 'f', -1, 64)
+	}
+	return strings.Join(labels, " ")
+}
+
+func formatSettings(userID int64, p UserPrefs) string {
+	risk := "off"
+	if p.RiskPct > 0 {
+		risk = strconv.FormatFloat(p.RiskPct, 'f', -1, 64) + "%"
+	}
+	text := fmt.Sprintf("⚙️ Settings\n📊 Lot presets: %s\n🎯 Default risk: %s\n🛑 SL/TP: %s\n🔔 Notifications: %s\n🕐 Timezone: %s (%s)\n🌐 Language: %s",
+		formatLotList(p.LotPresets), risk, p.SLTPPolicy, p.Verbosity, p.Timezone, time.Now().In(p.location()).Format("15:04 MST"), p.Language)
+	if !ownsSignalChat(userID) {
+		text += "\nℹ️ Signals go to a shared chat: its keyboard, notifications, timezone and language use the defaults. Your presets apply to your ✏️ Custom prompts and SL/TP to your taps."
+	}
+	return text
+}
+
+func settingsMenu(p UserPrefs) *TelegramInlineKeyboard {
+	return &TelegramInlineKeyboard{
+		InlineKeyboard: [][]TelegramInlineButton{
+			{
+				{Text: "📊 Lot presets", CallbackData: "set|lots"},
+				{Text: "🎯 Risk %", CallbackData: "set|risk"},
+			},
+			{
+				{Text: "🛑 SL/TP: " + p.SLTPPolicy, CallbackData: "set|sltp"},
+				{Text: "🔔 " + p.Verbosity, CallbackData: "set|verbosity"},
+			},
+			{
+				{Text: "🕐 Timezone", CallbackData: "set|tz"},
+				{Text: "🌐 " + p.Language, CallbackData: "set|lang"},
+			},
+			{
+				{Text: "♻️ Reset", CallbackData: "set|reset"},
+				{Text: "✖️ Close", CallbackData: "set|done"},
+			},
+		},
+	}
+}
+
+func handleSettingsCommand(from TelegramUser, chatID int64, args []string) {
+	p := getUserPrefs(from.ID)
+	sendTelegramWithButtonsTo(chatID, formatSettings(from.ID, p), settingsMenu(p))
+}
+
+// settingsPrompts - ForceReply /settings yang menunggu balasan; kadaluarsa setelah customLotTTL
+type settingsPrompt struct {
+	Field     string // lots | risk | tz
+	CreatedAt time.Time
+}
+
+var settingsMu sync.Mutex
+var settingsPrompts = map[replyPromptKey]settingsPrompt{}
+
+// handleSettingsCallback - set|lots / set|risk / set|tz (minta input), set|sltp / set|verbosity / set|lang (toggle), set|reset, set|done
+func handleSettingsCallback(callback *TelegramCallbackQuery, parts []string) {
+	if len(parts) < 2 {
+		return
+	}
+	user := TelegramUser{ID: callback.From.ID, Username: callback.From.Username}
+	chatID, messageID := callback.Message.Chat.ID, callback.Message.MessageID
+	p := getUserPrefs(user.ID)
+
+	switch field := parts[1]; field {
+	case "lots", "risk", "tz":
+		prompts := map[string][2]string{
+			"lots": {"reply with lot presets, e.g. 0.05 0.1 0.25 0.5", "0.05 0.1 0.25 0.5"},
+			"risk": {"reply with default risk % (0 = off)", "1.5"},
+			"tz":   {"reply with an IANA timezone, e.g. Europe/London", "Asia/Jakarta"},
+		}
+		answerCallbackQuery(callback.ID, "✏️ Reply with the new value")
+		sent, err := sendForceReply(chatID, fmt.Sprintf("⚙️ %s, %s", userLabel(callback), prompts[field][0]), prompts[field][1])
+		if err != nil {
+			log.Printf("⚠️ sendForceReply error: %v", err)
+			return
+		}
+		settingsMu.Lock()
+		for key, pending := range settingsPrompts {
+			if time.Since(pending.CreatedAt) > customLotTTL {
+				delete(settingsPrompts, key)
+			}
+		}
+		settingsPrompts[replyPromptKey{UserID: user.ID, PromptID: sent.MessageID}] = settingsPrompt{Field: field, CreatedAt: time.Now()}
+		settingsMu.Unlock()
+		return
+
+	case "sltp":
+		if p.SLTPPolicy == sltpATR {
+			p.SLTPPolicy = sltpFixed
+		} else {
+			p.SLTPPolicy = sltpATR
+		}
+	case "verbosity":
+		if p.Verbosity == verbosityAll {
+			p.Verbosity = verbosityActionable
+		} else {
+			p.Verbosity = verbosityAll
+		}
+	case "lang":
+		// Putar ke bahasa berikutnya di supportedLanguages
+		for i, lang := range supportedLanguages {
+			if lang == p.Language {
+				p.Language = supportedLanguages[(i+1)%len(supportedLanguages)]
+				break
+			}
+		}
+	case "reset":
+		p = defaultPrefs()
+	case "done":
+		answerCallbackQuery(callback.ID, "Saved")
+		if err := editMessageText(chatID, messageID, formatSettings(user.ID, p)); err != nil {
+			log.Printf("⚠️ editMessageText error: %v", err)
+		}
+		return
+	default:
+		return
+	}
+
+	saveUserPrefs(user.ID, p)
+	log.Printf("⚙️ Settings updated by user %d: %s", user.ID, parts[1])
+	answerCallbackQuery(callback.ID, "✅ Updated")
+	if err := editMessage(chatID, messageID, formatSettings(user.ID, p), settingsMenu(p)); err != nil {
+		log.Printf("⚠️ editMessage error: %v", err)
+	}
+}
+
+// handleSettingsReply - true jika pesan adalah balasan ke prompt /settings
+func handleSettingsReply(msg *TelegramIncomingMessage) bool {
+	if msg.ReplyToMessage == nil {
+		return false
+	}
+	key := replyPromptKey{UserID: msg.From.ID, PromptID: msg.ReplyToMessage.MessageID}
+
+	settingsMu.Lock()
+	prompt, ok := settingsPrompts[key]
+	delete(settingsPrompts, key)
+	settingsMu.Unlock()
+	if !ok {
+		return false
+	}
+	if time.Since(prompt.CreatedAt) > customLotTTL {
+		sendTelegramTo(msg.Chat.ID, "⌛ Settings prompt expired — open /settings again")
+		return true
+	}
+	field := prompt.Field
+
+	p := getUserPrefs(msg.From.ID)
+	input := strings.TrimSpace(msg.Text)
+	var err error
+	switch field {
+	case "lots":
+		var lots []float64
+		if lots, err = parseLotList(input); err == nil {
+			p.LotPresets = lots
+		}
+	case "risk":
+		var pct float64
+		if pct, err = strconv.ParseFloat(strings.TrimSuffix(input, "%"), 64); err != nil || pct < 0 || pct > 100 {
+			err = fmt.Errorf("invalid risk %q", input)
+		} else {
+			p.RiskPct = pct
+		}
+	case "tz":
+		if _, err = loadTimezone(input); err == nil {
+			p.Timezone = input
+		}
+	}
+	if err != nil {
+		sendTelegramTo(msg.Chat.ID, "❌ "+err.Error()+" — open /settings to try again")
+		return true
+	}
+
+	saveUserPrefs(msg.From.ID, p)
+	log.Printf("⚙️ Settings updated by user %d: %s=%q", msg.From.ID, field, input)
+	sendTelegramWithButtonsTo(msg.Chat.ID, formatSettings(msg.From.ID, p), settingsMenu(p))
+	return true
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ============ SCHEDULED P&L REPORTS ============
+// Laporan harian (DAILY_REPORT_TIME) dan mingguan (WEEKLY_REPORT_DAY + WEEKLY_REPORT_TIME)
+// dalam reportLocation(), dibangun dari journal (confirmation yang masuk lewat signalHandler).
+
+type PeriodReport struct {
+	Title     string
+	From      time.Time
+	To        time.Time
+	Opened    int
+	Signals   int
+	Ignored   int
+	Stats     *StatsReport
+	Best      *closedTrade
+	Worst     *closedTrade
+	OpenPos   []JournalTrade
+	OpenLots  map[string]float64 // symbol → net lots (BUY +, SELL -)
+	OpenCount map[string]int
+}
+
+// CountOpened - Jumlah trade yang dibuka di rentang waktu
+func (j *Journal) CountOpened(from, to int64) (int, error) {
+	var n int
+	err := j.db.QueryRow(`SELECT COUNT(*) FROM trades WHERE opened_at >= ? AND opened_at < ?`, from, to).Scan(&n)
+	return n, err
+}
+
+// CountSignalsByStatus - Jumlah open signal per status di rentang waktu
+func (j *Journal) CountSignalsByStatus(from, to int64) (map[string]int, error) {
+	rows, err := j.db.Query(
+		`SELECT status, COUNT(*) FROM signals
+		 WHERE received_at >= ? AND received_at < ? AND side NOT LIKE 'CLOSE_%'
+		 GROUP BY status`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
+// OpenTrades - Posisi yang sudah terkonfirmasi open dan belum close
+func (j *Journal) OpenTrades() ([]JournalTrade, error) {
+	return j.queryTrades(`WHERE opened_at IS NOT NULL AND closed_at IS NULL ORDER BY opened_at`)
+}
+
+func (j *Journal) BuildPeriodReport(title string, from, to time.Time) (*PeriodReport, error) {
+	report := &PeriodReport{Title: title, From: from, To: to, OpenLots: map[string]float64{}, OpenCount: map[string]int{}}
+
+	var err error
+	if report.Opened, err = j.CountOpened(from.Unix(), to.Unix()); err != nil {
+		return nil, err
+	}
+
+	counts, err := j.CountSignalsByStatus(from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+	for status, n := range counts {
+		report.Signals += n
+		if status == signalIgnored {
+			report.Ignored += n
+		}
+	}
+
+	if report.Stats, err = j.BuildStatsReport(from, to); err != nil {
+		return nil, err
+	}
+
+	trades, err := j.ClosedTrades(from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+	for i := range trades {
+		t := &trades[i]
+		if report.Best == nil || t.Profit > report.Best.Profit {
+			report.Best = t
+		}
+		if report.Worst == nil || t.Profit < report.Worst.Profit {
+			report.Worst = t
+		}
+	}
+
+	if report.OpenPos, err = j.OpenTrades(); err != nil {
+		return nil, err
+	}
+	for _, t := range report.OpenPos {
+		lots := t.Lots
+		if strings.EqualFold(t.Side, "SELL") {
+			lots = -lots
+		}
+		report.OpenLots[t.Symbol] += lots
+		report.OpenCount[t.Symbol]++
+	}
+	return report, nil
+}
+
+// reportLocation - Timezone laporan, /pnl, chart, /export dan /stats: DISPLAY_TIMEZONE
+// atau timezone /settings pemilik TELEGRAM_CHAT_ID (jika DM)
+func reportLocation() *time.Location {
+	return chatPrefs().location()
+}
+
+func formatPeriodReport(r *PeriodReport) string {
+	loc := reportLocation()
+	var b strings.Builder
+	fmt.Fprintf(&b, "📒 %s\n🗓️ %s - %s\n\n", r.Title,
+		r.From.In(loc).Format("02 Jan 15:04"), r.To.In(loc).Format("02 Jan 15:04 MST"))
+
+	overall := r.Stats.Overall
+	fmt.Fprintf(&b, "📥 Signals: %d | Ignored: %d\n", r.Signals, r.Ignored)
+	fmt.Fprintf(&b, "📊 Trades opened: %d | Closed: %d (W %d / L %d)\n", r.Opened, overall.Trades, overall.Wins, overall.Losses)
+	fmt.Fprintf(&b, "💵 Realized P&L: %+.2f\n", overall.NetProfit)
+
+	if r.Best != nil {
+		fmt.Fprintf(&b, "🏆 Best: #%d %s %s %+.2f\n", r.Best.Ticket, r.Best.Symbol, r.Best.Strategy, r.Best.Profit)
+	}
+	if r.Worst != nil && r.Worst != r.Best {
+		fmt.Fprintf(&b, "💀 Worst: #%d %s %s %+.2f\n", r.Worst.Ticket, r.Worst.Symbol, r.Worst.Strategy, r.Worst.Profit)
+	}
+
+	if len(r.Stats.ByStrategy) > 0 {
+		b.WriteString("\n🎯 P&L by Strategy\n")
+		for _, s := range r.Stats.ByStrategy {
+			if s.Trades > 0 {
+				fmt.Fprintf(&b, "• %s: %+.2f (%d tr)\n", s.Key, s.NetProfit, s.Trades)
+			}
+		}
+	}
+	if len(r.Stats.BySymbol) > 0 {
+		b.WriteString("\n💱 P&L by Symbol\n")
+		for _, s := range r.Stats.BySymbol {
+			if s.Trades > 0 {
+				fmt.Fprintf(&b, "• %s: %+.2f (%d tr)\n", s.Key, s.NetProfit, s.Trades)
+			}
+		}
+	}
+
+	b.WriteString("\n📂 Open Exposure\n")
+	if len(r.OpenPos) == 0 {
+		b.WriteString("(no open positions)\n")
+	} else {
+		symbols := make([]string, 0, len(r.OpenLots))
+		for symbol := range r.OpenLots {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+		for _, symbol := range symbols {
+			fmt.Fprintf(&b, "• %s: %+.2f lots (%d pos)\n", symbol, r.OpenLots[symbol], r.OpenCount[symbol])
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func sendPeriodReport(title string, from, to time.Time) {
+	if journal == nil {
+		return
+	}
+	report, err := journal.BuildPeriodReport(title, from, to)
+	if err != nil {
+		log.Printf("❌ report error: %v", err)
+		return
+	}
+	notify(msgReport, formatPeriodReport(report))
+	log.Printf("📒 %s sent", title)
+}
+
+func sendDailyReport(now time.Time) {
+	local := now.In(reportLocation())
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	sendPeriodReport("Daily Report", start, now)
+}
+
+func sendWeeklyReport(now time.Time) {
+	from := now.AddDate(0, 0, -7)
+	sendPeriodReport("Weekly Report", from, now)
+	for _, chatID := range routeChats(msgReport, "", "") {
+		if err := sendCharts(chatID, []string{"equity", "daily", "strategy"}, from, now); err != nil {
+			log.Printf("⚠️ weekly chart skipped: %v", err)
+			break
+		}
+	}
+}
+
+// reportSchedule - DAILY_REPORT_TIME / WEEKLY_REPORT_DAY / WEEKLY_REPORT_TIME yang sudah divalidasi
+type reportSchedule struct {
+	Daily, Weekly       int // menit sejak 00:00 (reportLocation), -1 = off
+	WeeklyDay           time.Weekday
+	DailySet, WeeklySet bool
+}
+
+// loadReportSchedule - Parse jam laporan; nilai salah (mis. "7:00") = error, bukan laporan yang diam-diam tidak pernah terkirim
+func loadReportSchedule() (reportSchedule, error) {
+	rs := reportSchedule{Daily: -1, Weekly: -1}
+	if !strings.EqualFold(config.DailyReportTime, "off") {
+		m, err := parseClock(config.DailyReportTime)
+		if err != nil {
+			return rs, fmt.Errorf("DAILY_REPORT_TIME: %v", err)
+		}
+		rs.Daily, rs.DailySet = m, true
+	}
+	if !strings.EqualFold(config.WeeklyReportDay, "off") && !strings.EqualFold(config.WeeklyReportTime, "off") {
+		day, ok := weekdayNames[strings.ToUpper(strings.TrimSpace(config.WeeklyReportDay))]
+		if !ok {
+			return rs, fmt.Errorf("WEEKLY_REPORT_DAY: unknown weekday %q (SUN..SAT or off)", config.WeeklyReportDay)
+		}
+		m, err := parseClock(config.WeeklyReportTime)
+		if err != nil {
+			return rs, fmt.Errorf("WEEKLY_REPORT_TIME: %v", err)
+		}
+		rs.Weekly, rs.WeeklyDay, rs.WeeklySet = m, day, true
+	}
+	return rs, nil
+}
+
+// startReportScheduler - Cek setiap 30 detik; tiap laporan dikirim sekali per hari/minggu
+func startReportScheduler(rs reportSchedule) {
+	if journal == nil || (!rs.DailySet && !rs.WeeklySet) {
+		return
+	}
+	log.Printf("📒 Report scheduler: daily=%s weekly=%s %s (%s)", config.DailyReportTime, config.WeeklyReportDay, config.WeeklyReportTime, reportLocation())
+
+	var lastDaily, lastWeekly string
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		local := now.In(reportLocation())
+		today := local.Format("2006-01-02")
+		minute := local.Hour()*60 + local.Minute()
+
+		if rs.DailySet && minute == rs.Daily && lastDaily != today {
+			lastDaily = today
+			sendDailyReport(now)
+		}
+		if rs.WeeklySet && local.Weekday() == rs.WeeklyDay && minute == rs.Weekly && lastWeekly != today {
+			lastWeekly = today
+			sendWeeklyReport(now)
+		}
+	}
+}
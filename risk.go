@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ============ RISK GUARDS ============
+// checkRiskGuards - Validasi trade sebelum di-enqueue. Limit bernilai 0 berarti nonaktif.
+// MAX_PENDING_OPENS dicek ulang saat enqueue lewat enqueueTradeGuarded.
+func checkRiskGuards(trade TradeCommand) error {
+	if trade.Lots <= 0 {
+		return fmt.Errorf("invalid lot size %.2f", trade.Lots)
+	}
+
+	if config.MaxLotSize > 0 && trade.Lots > config.MaxLotSize {
+		return fmt.Errorf("lot %.2f exceeds MAX_LOT_SIZE %.2f", trade.Lots, config.MaxLotSize)
+	}
+
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	return checkPendingOpensLocked()
+}
+
+// checkPendingOpensLocked - MAX_PENDING_OPENS; pemanggil memegang queueMu
+func checkPendingOpensLocked() error {
+	if config.MaxPendingOpens > 0 {
+		if pending := countQueuedOpensLocked(); pending >= config.MaxPendingOpens {
+			return fmt.Errorf("%d open commands still waiting for EA (MAX_PENDING_OPENS %d)", pending, config.MaxPendingOpens)
+		}
+	}
+	return nil
+}
+
+// errQueueClosed - Queue sudah disimpan untuk shutdown; perintah baru akan hilang jika di-enqueue
+var errQueueClosed = errors.New("shutting down")
+
+// enqueueTradeGuarded - Cek MAX_PENDING_OPENS dan enqueue dalam satu lock, sehingga
+// beberapa open yang bersamaan tidak bisa lolos bersama
+func enqueueTradeGuarded(trade TradeCommand) error {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	if queueClosed {
+		return errQueueClosed
+	}
+	if err := checkPendingOpensLocked(); err != nil {
+		return err
+	}
+	appendTradeLocked(trade)
+	return nil
+}
+
+// countQueuedOpens - Jumlah perintah open yang belum diambil EA
+func countQueuedOpens() int {
+	queueMu.Lock()
+	defer queueMu.Unlock()
+	return countQueuedOpensLocked()
+}
+
+func countQueuedOpensLocked() int {
+	count := 0
+	for _, cmd := range commandQueue {
+		if cmd.Action == "open" {
+			count++
+		}
+	}
+	return count
+}
+
+// ============ RISK PREVIEW ============
+// Risiko per trade dihitung dari jarak SL x contract size x lots (dalam quote currency),
+// lalu dikonversi ke mata uang akun jika quote = akun (xxxUSD di akun USD) atau
+// base = akun (USDJPY di akun USD, dibagi harga). Pair silang tetap dalam quote currency.
+// Balance diambil dari ACCOUNT_INFO terakhir yang dikirim EA, fallback ke ACCOUNT_BALANCE.
+
+var accountMu sync.Mutex
+var accountBalance float64
+var accountCurrency = "USD"
+
+// contractSize - Unit per 1 lot (GOLD_CONTRACT_SIZE / FOREX_CONTRACT_SIZE)
+func contractSize(symbol string) float64 {
+	if strings.Contains(symbol, "XAU") || strings.Contains(symbol, "GOLD") {
+		return config.GoldContractSize
+	}
+	return config.ForexContractSize
+}
+
+// currencyPair - Base dan quote currency dari 6 huruf pertama symbol (EURUSD.m → EUR, USD)
+func currencyPair(symbol string) (base, quote string, ok bool) {
+	symbol = strings.ToUpper(symbol)
+	if len(symbol) < 6 {
+		return "", "", false
+	}
+	for _, r := range symbol[:6] {
+		if r < 'A' || r > 'Z' {
+			return "", "", false
+		}
+	}
+	return symbol[:3], symbol[3:6], true
+}
+
+// recordAccountInfo - Simpan balance/currency dari ACCOUNT_INFO (reason = balance;equity;margin;free;currency;leverage)
+func recordAccountInfo(p SignalPayload) {
+	parts := strings.Split(p.Reason, ";")
+	balance, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || balance <= 0 {
+		return
+	}
+	accountMu.Lock()
+	defer accountMu.Unlock()
+	accountBalance = balance
+	if len(parts) > 4 && parts[4] != "" {
+		accountCurrency = parts[4]
+	}
+}
+
+// currentBalance - Balance terakhir dari EA, atau ACCOUNT_BALANCE jika EA belum pernah melapor
+func currentBalance() (float64, string) {
+	accountMu.Lock()
+	defer accountMu.Unlock()
+	if accountBalance > 0 {
+		return accountBalance, accountCurrency
+	}
+	return config.AccountBalance, accountCurrency
+}
+
+// tradeRisk - Kerugian jika SL kena, reward jika TP kena, R:R, dan mata uangnya
+// (mata uang akun jika bisa dikonversi, selain itu quote currency pair)
+func tradeRisk(trade TradeCommand) (risk, reward, rr float64, currency string) {
+	size := contractSize(trade.Symbol) * trade.Lots
+	risk = math.Abs(trade.Price-trade.SL) * size
+	reward = math.Abs(trade.TP-trade.Price) * size
+	if risk > 0 {
+		rr = reward / risk
+	}
+
+	_, currency = currentBalance()
+	base, quote, ok := currencyPair(trade.Symbol)
+	switch {
+	case !ok || quote == currency:
+		// symbol tanpa pair (mis. GOLD) dianggap dalam mata uang akun
+	case base == currency && trade.Price > 0:
+		risk /= trade.Price
+		reward /= trade.Price
+	default:
+		currency = quote
+	}
+	return risk, reward, rr, currency
+}
+
+// riskPercent - Risiko sebagai % balance (0 jika balance tidak diketahui atau
+// risiko tidak dalam mata uang akun)
+func riskPercent(risk float64, currency string) float64 {
+	balance, accCurrency := currentBalance()
+	if balance <= 0 || currency != accCurrency {
+		return 0
+	}
+	return risk / balance * 100
+}
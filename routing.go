@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// ============ NOTIFICATION ROUTING ============
+// NOTIFY_ROUTES membagi notifikasi ke beberapa tujuan berdasarkan jenis pesan,
+// strategi dan symbol. Aturan dipisah ";", formatnya:
+//
+//	<chat_id | discord:NAME | slack:NAME> [type=open,close] [strategy=EMA_*,BREAKOUT] [symbol=XAUUSD] [buttons=off]
+//
+// Filter kosong = semua. Setiap route yang cocok mendapat salinan pesan; jika tidak
+// ada yang cocok, pesan dikirim ke TELEGRAM_CHAT_ID seperti biasa. buttons=off
+// mengirim signal tanpa tombol eksekusi (mis. channel publik). Tombol eksekusi hanya
+// dikirim ke satu chat Telegram (lihat dispatchRouted). Balasan command tidak di-route:
+// dikirim ke chat asal command.
+
+// Jenis pesan yang bisa di-route
+const (
+	msgOpen         = "open"         // open signal (termasuk blackout / EA offline / auto execute)
+	msgClose        = "close"        // close signal
+	msgConfirmation = "confirmation" // ORDER OPENED / ORDER CLOSED dari EA
+	msgAccount      = "account"      // ACCOUNT_INFO / ORDERS_STATUS
+	msgReport       = "report"       // laporan harian / mingguan (P&L) dan chart-nya
+	msgError        = "error"        // EA offline dan error lain yang perlu ditindak
+	msgSystem       = "system"       // startup / shutdown / EA kembali online
+)
+
+var messageTypes = []string{msgOpen, msgClose, msgConfirmation, msgAccount, msgReport, msgError, msgSystem}
+
+// Alias: "signal" = open + close
+var messageTypeAliases = map[string][]string{"signal": {msgOpen, msgClose}}
+
+type notifyRoute struct {
+	Target     notifier
+	Types      map[string]bool
+	Strategies []string // uppercase; akhiran * = prefix
+	Symbols    []string
+	NoButtons  bool
+}
+
+var notifyRoutes []notifyRoute
+
+// parseNotifyRoutes - Parse NOTIFY_ROUTES; error menyebut aturan yang salah
+func parseNotifyRoutes(spec string) ([]notifyRoute, error) {
+	var routes []notifyRoute
+	for _, rule := range strings.Split(spec, ";") {
+		fields := strings.Fields(rule)
+		if len(fields) == 0 {
+			continue
+		}
+		target, err := parseNotifyTarget(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("route %q: %v", strings.TrimSpace(rule), err)
+		}
+		route := notifyRoute{Target: target, Types: map[string]bool{}}
+
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok || value == "" {
+				return nil, fmt.Errorf("route %q: expected key=value, got %q", strings.TrimSpace(rule), field)
+			}
+			values := strings.Split(value, ",")
+			switch strings.ToLower(key) {
+			case "type":
+				for _, t := range values {
+					t = strings.ToLower(t)
+					if alias, ok := messageTypeAliases[t]; ok {
+						for _, a := range alias {
+							route.Types[a] = true
+						}
+						continue
+					}
+					if !containsString(messageTypes, t) {
+						return nil, fmt.Errorf("route %q: unknown type %q (%s, signal)", strings.TrimSpace(rule), t, strings.Join(messageTypes, ", "))
+					}
+					route.Types[t] = true
+				}
+			case "strategy":
+				route.Strategies = append(route.Strategies, upperAll(values)...)
+			case "symbol":
+				route.Symbols = append(route.Symbols, upperAll(values)...)
+			case "buttons":
+				switch strings.ToLower(value) {
+				case "off", "false", "no":
+					route.NoButtons = true
+				case "on", "true", "yes":
+				default:
+					return nil, fmt.Errorf("route %q: buttons must be on or off", strings.TrimSpace(rule))
+				}
+			default:
+				return nil, fmt.Errorf("route %q: unknown key %q (type, strategy, symbol, buttons)", strings.TrimSpace(rule), key)
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// parseNotifyTarget - chat id Telegram, discord:NAME atau slack:NAME
+func parseNotifyTarget(target string) (notifier, error) {
+	if strings.Contains(target, "=") {
+		return nil, fmt.Errorf("chat id or sink must come first")
+	}
+	if kind, name, ok := strings.Cut(target, ":"); ok {
+		kind = strings.ToLower(kind)
+		if kind != sinkDiscord && kind != sinkSlack {
+			return nil, fmt.Errorf("unknown sink %q (discord, slack)", kind)
+		}
+		return getWebhookNotifier(kind, name)
+	}
+	return telegramNotifier{ChatID: target}, nil
+}
+
+func upperAll(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.ToUpper(strings.TrimSpace(v)); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern - Daftar kosong = semua; "EMA_*" cocok dengan prefix
+func matchPattern(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	value = strings.ToUpper(value)
+	for _, p := range patterns {
+		if p == value || (strings.HasSuffix(p, "*") && strings.HasPrefix(value, strings.TrimSuffix(p, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r notifyRoute) matches(msgType, strategy, symbol string) bool {
+	if len(r.Types) > 0 && !r.Types[msgType] {
+		return false
+	}
+	return matchPattern(r.Strategies, strategy) && matchPattern(r.Symbols, symbol)
+}
+
+// routesFor - Route yang cocok, satu per tujuan (tombol boleh jika salah satu route membolehkan);
+// tanpa route cocok = TELEGRAM_CHAT_ID (dengan tombol)
+func routesFor(msgType, strategy, symbol string) []notifyRoute {
+	var matched []notifyRoute
+	index := map[string]int{}
+	for _, r := range notifyRoutes {
+		if !r.matches(msgType, strategy, symbol) {
+			continue
+		}
+		if i, ok := index[r.Target.Name()]; ok {
+			matched[i].NoButtons = matched[i].NoButtons && r.NoButtons
+			continue
+		}
+		index[r.Target.Name()] = len(matched)
+		matched = append(matched, r)
+	}
+	if len(matched) == 0 {
+		matched = []notifyRoute{{Target: defaultNotifier()}}
+	}
+	return matched
+}
+
+// routeChats - Chat Telegram tujuan untuk satu jenis pesan (mis. chart laporan mingguan)
+func routeChats(msgType, strategy, symbol string) []string {
+	var chats []string
+	for _, r := range routesFor(msgType, strategy, symbol) {
+		if t, ok := r.Target.(telegramNotifier); ok {
+			chats = append(chats, t.ChatID)
+		}
+	}
+	return chats
+}
+
+// notify - Kirim pesan teks biasa ke semua tujuan untuk jenis pesan ini
+func notify(msgType, text string) {
+	for _, r := range routesFor(msgType, "", "") {
+		r.Target.Notify(notification{Type: msgType, Message: plainMessage(text)})
+	}
+}
+
+// dispatchRouted - Kirim pesan signal ke setiap route. Tombol hanya ikut di salinan utama:
+// chat Telegram pertama tanpa buttons=off, atau TELEGRAM_CHAT_ID jika belum ada route
+// Telegram yang membolehkan tombol. Salinan lain di-render tanpa tombol (Buttons = false).
+// onSent hanya untuk salinan utama, yang dicatat journal dan dipakai auto execute.
+func dispatchRouted(msgType string, p SignalPayload, msg message, buttons *TelegramInlineKeyboard, onSent func(*TelegramSentMessage, message, error)) {
+	routes := routesFor(msgType, p.Strategy, p.Symbol)
+	primary, firstTelegram := -1, -1
+	defaultName := defaultNotifier().Name()
+	hasDefault := false
+	for i, r := range routes {
+		if _, ok := r.Target.(telegramNotifier); !ok {
+			continue
+		}
+		if firstTelegram < 0 {
+			firstTelegram = i
+		}
+		if r.Target.Name() == defaultName {
+			hasDefault = true
+		}
+		if primary < 0 && buttons != nil && !r.NoButtons {
+			primary = i
+		}
+	}
+	switch {
+	case primary >= 0:
+	case buttons != nil && !hasDefault:
+		routes = append(routes, notifyRoute{Target: defaultNotifier()})
+		primary = len(routes) - 1
+	default:
+		primary = firstTelegram
+		if buttons != nil {
+			log.Printf("⚠️ Signal buttons dropped: every Telegram route for %s %s has buttons=off", p.Symbol, p.Strategy)
+		}
+	}
+
+	for i, r := range routes {
+		withButtons := i == primary && buttons != nil && !r.NoButtons
+		routed := msg.withButtons(withButtons && msg.Data.Buttons)
+		n := notification{Type: msgType, Strategy: p.Strategy, Symbol: p.Symbol, Message: routed}
+		if withButtons {
+			n.Buttons = buttons
+		}
+		if i == primary {
+			n.OnSent = func(sent *TelegramSentMessage, err error) { onSent(sent, routed, err) }
+		} else {
+			target := r.Target.Name()
+			n.OnSent = func(sent *TelegramSentMessage, err error) {
+				if err != nil {
+					log.Printf("❌ Telegram error (route %s): %v", target, err)
+				}
+			}
+		}
+		r.Target.Notify(n)
+	}
+}
+
+// formatRoutes - Ringkasan NOTIFY_ROUTES untuk log startup
+func formatRoutes() string {
+	if len(notifyRoutes) == 0 {
+		return "all → " + config.TelegramChatID
+	}
+	var lines []string
+	for _, r := range notifyRoutes {
+		line := r.Target.Name()
+		if len(r.Types) > 0 {
+			line += " type=" + strings.Join(sortedKeys(r.Types), ",")
+		}
+		if len(r.Strategies) > 0 {
+			line += " strategy=" + strings.Join(r.Strategies, ",")
+		}
+		if len(r.Symbols) > 0 {
+			line += " symbol=" + strings.Join(r.Symbols, ",")
+		}
+		if r.NoButtons {
+			line += " buttons=off"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "; ")
+}
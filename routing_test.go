@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// routeSummary - "target types=a,b strategy=X symbol=Y buttons=off" untuk perbandingan
+func routeSummary(r notifyRoute) string {
+	var types []string
+	for t := range r.Types {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	s := fmt.Sprintf("%s types=%s strategy=%s symbol=%s", r.Target.Name(), strings.Join(types, ","), strings.Join(r.Strategies, ","), strings.Join(r.Symbols, ","))
+	if r.NoButtons {
+		s += " buttons=off"
+	}
+	return s
+}
+
+func TestParseNotifyRoutes(t *testing.T) {
+	t.Setenv("DISCORD_WEBHOOK_OPS", "http://127.0.0.1:1/discord")
+
+	tests := []struct {
+		name    string
+		spec    string
+		want    []string
+		wantErr string
+	}{
+		{name: "empty", spec: " ; "},
+		{name: "chat only", spec: "-100123", want: []string{"-100123 types= strategy= symbol="}},
+		{
+			name: "filters and buttons",
+			spec: "-100123 type=open,Close strategy=ema_*,breakout symbol=xauusd buttons=off",
+			want: []string{"-100123 types=close,open strategy=EMA_*,BREAKOUT symbol=XAUUSD buttons=off"},
+		},
+		{name: "signal alias", spec: "42 type=signal,report", want: []string{"42 types=close,open,report strategy= symbol="}},
+		{
+			name: "several rules and sink",
+			spec: "-100123 type=signal; discord:ops type=error,system ;42 buttons=on",
+			want: []string{
+				"-100123 types=close,open strategy= symbol=",
+				"discord:OPS types=error,system strategy= symbol=",
+				"42 types= strategy= symbol=",
+			},
+		},
+		{name: "unknown type", spec: "-100123 type=opens", wantErr: `unknown type "opens"`},
+		{name: "unknown key", spec: "-100123 chat=1", wantErr: `unknown key "chat"`},
+		{name: "missing value", spec: "-100123 type=", wantErr: "expected key=value"},
+		{name: "bad buttons", spec: "-100123 buttons=maybe", wantErr: "buttons must be on or off"},
+		{name: "filter first", spec: "type=open -100123", wantErr: "chat id or sink must come first"},
+		{name: "unknown sink", spec: "teams:ops", wantErr: `unknown sink "teams"`},
+		{name: "sink without url", spec: "slack:nowhere", wantErr: "SLACK_WEBHOOK_NOWHERE not set"},
+		{name: "error names rule", spec: "-1 type=open; -2 type=nope", wantErr: `route "-2 type=nope"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, err := parseNotifyRoutes(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseNotifyRoutes: %v", err)
+			}
+			var got []string
+			for _, r := range routes {
+				got = append(got, routeSummary(r))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("routes\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestNotifyRouteMatches(t *testing.T) {
+	routes, err := parseNotifyRoutes("1 type=open strategy=EMA_* symbol=XAUUSD,EURUSD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := routes[0]
+
+	tests := []struct {
+		msgType, strategy, symbol string
+		want                      bool
+	}{
+		{msgOpen, "EMA_PULLBACK", "XAUUSD", true},
+		{msgOpen, "ema_cross", "eurusd", true},
+		{msgOpen, "BREAKOUT", "XAUUSD", false},
+		{msgOpen, "EMA_PULLBACK", "GBPUSD", false},
+		{msgClose, "EMA_PULLBACK", "XAUUSD", false},
+	}
+	for _, tt := range tests {
+		if got := r.matches(tt.msgType, tt.strategy, tt.symbol); got != tt.want {
+			t.Errorf("matches(%s, %s, %s) = %v, want %v", tt.msgType, tt.strategy, tt.symbol, got, tt.want)
+		}
+	}
+}
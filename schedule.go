@@ -0,0 +1,422 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============ TRADING SCHEDULE ============
+// Sesi mingguan (TRADING_SESSIONS), hari libur (HOLIDAYS) dan blackout berita
+// (NEWS_CALENDAR_FILE, format .ics atau .csv). Di luar sesi / saat blackout,
+// open signal diberi label dan auto execute dimatikan. BLACKOUT_SUPPRESS_BUTTONS=true
+// juga menyembunyikan tombol lot dan menolak trade manual; false tetap mengizinkannya.
+
+// sessionWindow - Jendela sesi harian dalam menit sejak 00:00 (End <= Start = lewat tengah malam)
+type sessionWindow struct {
+	Days  map[time.Weekday]bool
+	Start int
+	End   int
+}
+
+type blackoutWindow struct {
+	Start time.Time
+	End   time.Time
+	Title string
+}
+
+type tradingSchedule struct {
+	mu          sync.RWMutex
+	location    *time.Location
+	sessions    []sessionWindow
+	holidays    map[string]bool // key: 2006-01-02
+	blackouts   []blackoutWindow
+	newsFile    string
+	newsModTime time.Time
+}
+
+var schedule = &tradingSchedule{location: time.UTC, holidays: map[string]bool{}}
+
+var weekdayNames = map[string]time.Weekday{
+	"SUN": time.Sunday, "MON": time.Monday, "TUE": time.Tuesday, "WED": time.Wednesday,
+	"THU": time.Thursday, "FRI": time.Friday, "SAT": time.Saturday,
+}
+
+func loadSchedule() error {
+	loc, err := time.LoadLocation(config.ScheduleTimezone)
+	if err != nil {
+		return fmt.Errorf("invalid SCHEDULE_TIMEZONE %q: %v", config.ScheduleTimezone, err)
+	}
+
+	sessions, err := parseSessions(config.TradingSessions)
+	if err != nil {
+		return err
+	}
+
+	holidays := map[string]bool{}
+	for _, day := range strings.Split(config.Holidays, ",") {
+		day = strings.TrimSpace(day)
+		if day == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			return fmt.Errorf("invalid HOLIDAYS date %q", day)
+		}
+		holidays[day] = true
+	}
+
+	schedule.mu.Lock()
+	schedule.location = loc
+	schedule.sessions = sessions
+	schedule.holidays = holidays
+	schedule.newsFile = config.NewsCalendarFile
+	schedule.mu.Unlock()
+
+	log.Printf("🗓️  Schedule: %d session window(s), %d holiday(s), tz=%s", len(sessions), len(holidays), loc)
+
+	if config.NewsCalendarFile != "" {
+		return schedule.reloadNews()
+	}
+	return nil
+}
+
+// parseSessions - Format: "MON-FRI 07:00-23:00;SUN 22:00-23:59"
+func parseSessions(spec string) ([]sessionWindow, error) {
+	var sessions []sessionWindow
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Fields(part)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid session %q (expected \"MON-FRI 07:00-23:00\")", part)
+		}
+
+		days, err := parseWeekdays(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid session %q: %v", part, err)
+		}
+
+		times := strings.Split(fields[1], "-")
+		if len(times) != 2 {
+			return nil, fmt.Errorf("invalid session hours %q", fields[1])
+		}
+		start, err := parseClock(times[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(times[1])
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, sessionWindow{Days: days, Start: start, End: end})
+	}
+	return sessions, nil
+}
+
+// parseWeekdays - "MON-FRI", "SAT,SUN" atau "WED"
+func parseWeekdays(spec string) (map[time.Weekday]bool, error) {
+	days := map[time.Weekday]bool{}
+	for _, item := range strings.Split(strings.ToUpper(spec), ",") {
+		bounds := strings.Split(item, "-")
+		from, ok := weekdayNames[bounds[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", bounds[0])
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, ok = weekdayNames[bounds[1]]; !ok {
+				return nil, fmt.Errorf("unknown weekday %q", bounds[1])
+			}
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (w sessionWindow) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	if w.Start < w.End {
+		return w.Days[t.Weekday()] && minute >= w.Start && minute < w.End
+	}
+	// Sesi lewat tengah malam: bagian akhir milik hari sebelumnya
+	yesterday := (t.Weekday() + 6) % 7
+	return (w.Days[t.Weekday()] && minute >= w.Start) || (w.Days[yesterday] && minute < w.End)
+}
+
+// reloadNews - Muat ulang kalender berita bila file berubah
+func (s *tradingSchedule) reloadNews() error {
+	s.mu.RLock()
+	path, lastMod, loc := s.newsFile, s.newsModTime, s.location
+	s.mu.RUnlock()
+
+	if path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("news calendar not found: %v", err)
+	}
+	if info.ModTime().Equal(lastMod) {
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open news calendar: %v", err)
+	}
+	defer f.Close()
+
+	pad := time.Duration(config.NewsBlackoutMinutes) * time.Minute
+	var blackouts []blackoutWindow
+	if strings.EqualFold(filepath.Ext(path), ".ics") {
+		blackouts, err = parseICal(f, loc, pad)
+	} else {
+		blackouts, err = parseBlackoutCSV(f, loc, pad)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse news calendar %s: %v", path, err)
+	}
+	sort.Slice(blackouts, func(i, j int) bool { return blackouts[i].Start.Before(blackouts[j].Start) })
+
+	s.mu.Lock()
+	s.blackouts = blackouts
+	s.newsModTime = info.ModTime()
+	s.mu.Unlock()
+
+	log.Printf("📰 News calendar loaded: %d blackout window(s) from %s", len(blackouts), path)
+	return nil
+}
+
+// parseBlackoutCSV - Kolom: start,end,title. End kosong = start ± NEWS_BLACKOUT_MINUTES.
+func parseBlackoutCSV(r io.Reader, loc *time.Location, pad time.Duration) ([]blackoutWindow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var blackouts []blackoutWindow
+	for i, row := range rows {
+		if len(row) == 0 || strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		start, err := parseScheduleTime(row[0], loc)
+		if err != nil {
+			if i == 0 {
+				continue // header
+			}
+			return nil, fmt.Errorf("line %d: %v", i+1, err)
+		}
+
+		window := blackoutWindow{Start: start.Add(-pad), End: start.Add(pad)}
+		if len(row) >= 2 && strings.TrimSpace(row[1]) != "" {
+			end, err := parseScheduleTime(row[1], loc)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			window = blackoutWindow{Start: start, End: end}
+		}
+		if len(row) >= 3 {
+			window.Title = strings.TrimSpace(strings.Join(row[2:], ","))
+		}
+		blackouts = append(blackouts, window)
+	}
+	return blackouts, nil
+}
+
+func parseScheduleTime(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02 15:04:05"} {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// parseICal - Ambil VEVENT (DTSTART/DTEND/SUMMARY). Event seharian menjadi blackout satu hari penuh.
+func parseICal(r io.Reader, loc *time.Location, pad time.Duration) ([]blackoutWindow, error) {
+	// Gabungkan baris yang di-fold (diawali spasi/tab)
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var blackouts []blackoutWindow
+	var inEvent, allDay bool
+	var start, end time.Time
+	var title string
+	for _, line := range lines {
+		switch {
+		case line == "BEGIN:VEVENT":
+			inEvent, allDay = true, false
+			start, end, title = time.Time{}, time.Time{}, ""
+		case line == "END:VEVENT":
+			inEvent = false
+			if start.IsZero() {
+				continue
+			}
+			window := blackoutWindow{Start: start, End: end, Title: title}
+			if end.IsZero() {
+				if allDay {
+					window.End = start.AddDate(0, 0, 1)
+				} else {
+					window = blackoutWindow{Start: start.Add(-pad), End: start.Add(pad), Title: title}
+				}
+			}
+			blackouts = append(blackouts, window)
+		case inEvent:
+			name, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			prop, params, _ := strings.Cut(name, ";")
+			switch prop {
+			case "DTSTART", "DTEND":
+				t, isDate, err := parseICalTime(value, params, loc)
+				if err != nil {
+					return nil, err
+				}
+				if prop == "DTSTART" {
+					start, allDay = t, isDate
+				} else {
+					end = t
+				}
+			case "SUMMARY":
+				title = strings.ReplaceAll(value, "\\,", ",")
+			}
+		}
+	}
+	return blackouts, nil
+}
+
+func parseICalTime(value, params string, loc *time.Location) (time.Time, bool, error) {
+	for _, param := range strings.Split(params, ";") {
+		if tzid, ok := strings.CutPrefix(param, "TZID="); ok {
+			if l, err := time.LoadLocation(tzid); err == nil {
+				loc = l
+			}
+		}
+	}
+
+	if strings.Contains(params, "VALUE=DATE") && !strings.Contains(params, "VALUE=DATE-TIME") {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// refuseIfTradingBlocked - Cek ulang jadwal saat tombol eksekusi ditekan: signal bisa
+// terkirim sebelum sesi tutup / blackout dimulai
+func refuseIfTradingBlocked(callback *TelegramCallbackQuery) bool {
+	reason := manualTradingBlockedReason(time.Now())
+	if reason == "" {
+		return false
+	}
+	log.Printf("⛔ Trade refused: %s", reason)
+	answerCallbackQuery(callback.ID, "⛔ "+reason)
+	return true
+}
+
+// manualTradingBlockedReason - Seperti tradingBlockedReason, tapi trade manual tetap boleh
+// bila BLACKOUT_SUPPRESS_BUTTONS=false (hanya auto execute yang ditahan)
+func manualTradingBlockedReason(t time.Time) string {
+	if !config.BlackoutSuppressButtons {
+		return ""
+	}
+	return tradingBlockedReason(t)
+}
+
+// tradingBlockedReason - Alasan trading tidak diizinkan pada waktu t, "" jika boleh
+func tradingBlockedReason(t time.Time) string {
+	if err := schedule.reloadNews(); err != nil {
+		log.Printf("⚠️  News calendar reload error: %v", err)
+	}
+
+	schedule.mu.RLock()
+	defer schedule.mu.RUnlock()
+
+	local := t.In(schedule.location)
+	if schedule.holidays[local.Format("2006-01-02")] {
+		return "Holiday " + local.Format("2006-01-02")
+	}
+
+	for _, b := range schedule.blackouts {
+		if !t.Before(b.Start) && t.Before(b.End) {
+			title := b.Title
+			if title == "" {
+				title = "news event"
+			}
+			return fmt.Sprintf("News blackout: %s (%s-%s)", title,
+				b.Start.In(schedule.location).Format("15:04"), b.End.In(schedule.location).Format("15:04"))
+		}
+	}
+
+	if len(schedule.sessions) == 0 {
+		return ""
+	}
+	for _, w := range schedule.sessions {
+		if w.contains(local) {
+			return ""
+		}
+	}
+	return "Outside trading session"
+}
+
+// buildBlackoutSignal - Open signal yang masuk saat trading tidak diizinkan
+func buildBlackoutSignal(p SignalPayload, ts string, reason string, lang string) (message, *TelegramInlineKeyboard) {
+	log.Printf("⛔ Signal during blackout: %s %s strat=%s reason=%s", p.Symbol, p.Side, p.Strategy, reason)
+
+	data := messageData{SignalPayload: p, Lang: lang, Time: ts, Note: reason, Buttons: !config.BlackoutSuppressButtons}
+	if !data.Buttons {
+		return templateMessage("blackout_signal", data), nil
+	}
+	return templateMessage("blackout_signal", data), openSignalButtons(p)
+}
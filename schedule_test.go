@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testConfig - Pasang config global untuk satu test (config lama dikembalikan saat selesai)
+func testConfig(t *testing.T, c Config) {
+	t.Helper()
+	old := config
+	config = &c
+	t.Cleanup(func() { config = old })
+}
+
+func TestParseSessions(t *testing.T) {
+	// 2024-01-01 adalah Senin
+	at := func(day int, clock string) time.Time {
+		ts, err := time.Parse("2006-01-02 15:04", fmt.Sprintf("2024-01-%02d %s", day, clock))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+		open    []time.Time
+		closed  []time.Time
+	}{
+		{name: "empty", spec: ""},
+		{
+			name:   "weekday range",
+			spec:   "MON-FRI 07:00-23:00",
+			open:   []time.Time{at(1, "07:00"), at(5, "22:59")},
+			closed: []time.Time{at(1, "06:59"), at(1, "23:00"), at(6, "12:00")},
+		},
+		{
+			name:   "overnight belongs to the start day",
+			spec:   "SUN 22:00-02:00",
+			open:   []time.Time{at(7, "23:30"), at(8, "01:59")},
+			closed: []time.Time{at(7, "21:59"), at(8, "02:00"), at(1, "23:30")},
+		},
+		{
+			name:   "list and lowercase",
+			spec:   " sat,sun 10:00-12:00 ; WED 08:00-09:00 ",
+			open:   []time.Time{at(6, "10:00"), at(7, "11:59"), at(3, "08:30")},
+			closed: []time.Time{at(4, "08:30"), at(6, "12:00")},
+		},
+		{name: "missing hours", spec: "MON-FRI", wantErr: "invalid session"},
+		{name: "unknown day", spec: "MON-FRY 07:00-23:00", wantErr: "unknown weekday"},
+		{name: "bad clock", spec: "MON 7am-23:00", wantErr: "invalid time"},
+		{name: "bad range", spec: "MON 07:00", wantErr: "invalid session hours"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions, err := parseSessions(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSessions: %v", err)
+			}
+			inSession := func(ts time.Time) bool {
+				for _, s := range sessions {
+					if s.contains(ts) {
+						return true
+					}
+				}
+				return false
+			}
+			for _, ts := range tt.open {
+				if !inSession(ts) {
+					t.Errorf("%s %s: want open", ts.Weekday(), ts.Format("15:04"))
+				}
+			}
+			for _, ts := range tt.closed {
+				if inSession(ts) {
+					t.Errorf("%s %s: want closed", ts.Weekday(), ts.Format("15:04"))
+				}
+			}
+		})
+	}
+}
+
+func TestReloadNewsCalendar(t *testing.T) {
+	testConfig(t, Config{NewsBlackoutMinutes: 30})
+	loc := time.FixedZone("WIB", 7*60*60)
+	utc := func(s string) time.Time {
+		ts, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		body    string
+		want    []blackoutWindow
+		wantErr string
+	}{
+		{
+			name: "csv with header, padding and explicit end",
+			file: "news.csv",
+			body: "start,end,title\n" +
+				"# komentar\n" +
+				"2024-03-08 19:30,,USD NFP\n" +
+				"2024-03-07T13:00:00Z,2024-03-07T14:00:00Z,ECB, press conference\n",
+			want: []blackoutWindow{
+				{Start: utc("2024-03-07 13:00"), End: utc("2024-03-07 14:00"), Title: "ECB,press conference"},
+				{Start: utc("2024-03-08 12:00"), End: utc("2024-03-08 13:00"), Title: "USD NFP"},
+			},
+		},
+		{
+			name: "ics with utc, tzid, all-day and folded summary",
+			file: "news.ics",
+			body: "BEGIN:VCALENDAR\r\n" +
+				"BEGIN:VEVENT\r\n" +
+				"DTSTART:20240308T133000Z\r\n" +
+				"SUMMARY:USD Non-Farm\r\n" +
+				"  Payrolls\r\n" +
+				"END:VEVENT\r\n" +
+				"BEGIN:VEVENT\r\n" +
+				"DTSTART;TZID=Europe/London:20240307T131500\r\n" +
+				"DTEND;TZID=Europe/London:20240307T134500\r\n" +
+				"SUMMARY:ECB Rate\\, Statement\r\n" +
+				"END:VEVENT\r\n" +
+				"BEGIN:VEVENT\r\n" +
+				"DTSTART;VALUE=DATE:20240325\r\n" +
+				"SUMMARY:Holiday\r\n" +
+				"END:VEVENT\r\n" +
+				"END:VCALENDAR\r\n",
+			want: []blackoutWindow{
+				{Start: utc("2024-03-07 13:15"), End: utc("2024-03-07 13:45"), Title: "ECB Rate, Statement"},
+				{Start: utc("2024-03-08 13:00"), End: utc("2024-03-08 14:00"), Title: "USD Non-Farm Payrolls"},
+				{Start: utc("2024-03-24 17:00"), End: utc("2024-03-25 17:00"), Title: "Holiday"},
+			},
+		},
+		{name: "csv bad time", file: "bad.csv", body: "start,end,title\n2024-03-08 19:30,tomorrow,NFP\n", wantErr: "line 2"},
+		{name: "ics bad time", file: "bad.ics", body: "BEGIN:VEVENT\nDTSTART:soon\nEND:VEVENT\n", wantErr: "failed to parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.body), 0644); err != nil {
+				t.Fatal(err)
+			}
+			s := &tradingSchedule{location: loc, holidays: map[string]bool{}, newsFile: path}
+			err := s.reloadNews()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reloadNews: %v", err)
+			}
+			if len(s.blackouts) != len(tt.want) {
+				t.Fatalf("blackouts = %+v, want %d", s.blackouts, len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := s.blackouts[i]
+				if !got.Start.Equal(want.Start) || !got.End.Equal(want.End) || got.Title != want.Title {
+					t.Errorf("blackout %d = %s-%s %q, want %s-%s %q", i,
+						got.Start.UTC().Format(time.RFC3339), got.End.UTC().Format(time.RFC3339), got.Title,
+						want.Start.Format(time.RFC3339), want.End.Format(time.RFC3339), want.Title)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ============ GRACEFUL SHUTDOWN ============
+// SIGINT/SIGTERM: berhenti menerima request, tunggu handler selesai, kosongkan outbox
+// Telegram, simpan queue perintah yang belum diambil EA ke QUEUE_STATE_PATH, lalu kirim
+// notifikasi offline.
+// Queue dipulihkan saat startup berikutnya.
+
+const shutdownTimeout = 15 * time.Second
+
+// queueClosed - true sejak queue mulai disimpan saat shutdown (dijaga queueMu); auto execute
+// yang terlambat ditolak karena perintahnya tidak akan ikut tersimpan
+var queueClosed bool
+
+// persistedCommand - TradeCommand + waktu enqueue (field unexported tidak ikut JSON)
+type persistedCommand struct {
+	TradeCommand
+	EnqueuedAt int64 `json:"enqueued_at"`
+}
+
+// saveQueue - Tulis queue ke file (atomic rename); file dihapus jika queue kosong
+func saveQueue(path string) (int, error) {
+	queueMu.Lock()
+	cmds := make([]persistedCommand, len(commandQueue))
+	for i, cmd := range commandQueue {
+		cmds[i] = persistedCommand{TradeCommand: cmd, EnqueuedAt: cmd.enqueuedAt.Unix()}
+	}
+	queueMu.Unlock()
+
+	if len(cmds) == 0 {
+		os.Remove(path)
+		return 0, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, err
+	}
+	data, err := json.MarshalIndent(cmds, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return 0, err
+	}
+	return len(cmds), os.Rename(tmp, path)
+}
+
+// restoreQueue - Muat queue dari shutdown sebelumnya (di depan perintah baru). Perintah open
+// yang lebih tua dari QUEUE_MAX_OPEN_AGE_SEC tidak dipulihkan: harga, SL dan TP-nya sudah basi.
+func restoreQueue(path string) (int, []TradeCommand, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil, nil
+	} else if err != nil {
+		return 0, nil, err
+	}
+
+	var cmds []persistedCommand
+	if err := json.Unmarshal(data, &cmds); err != nil {
+		return 0, nil, fmt.Errorf("invalid queue file %s: %v", path, err)
+	}
+
+	maxAge := time.Duration(config.QueueMaxOpenAgeSec) * time.Second
+	var restored, expired []TradeCommand
+	for _, c := range cmds {
+		cmd := c.TradeCommand
+		cmd.enqueuedAt = time.Unix(c.EnqueuedAt, 0)
+		if cmd.Action == "open" && maxAge > 0 && time.Since(cmd.enqueuedAt) > maxAge {
+			expired = append(expired, cmd)
+			continue
+		}
+		restored = append(restored, cmd)
+	}
+
+	queueMu.Lock()
+	commandQueue = append(restored, commandQueue...)
+	queueMu.Unlock()
+
+	os.Remove(path)
+	return len(restored), expired, nil
+}
+
+// notifyExpiredOpens - Laporkan open yang dibuang saat restore (tidak dikirim ke EA)
+func notifyExpiredOpens(expired []TradeCommand) {
+	if len(expired) == 0 {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "⌛ %d queued open command(s) expired during restart (older than %ds), not sent to EA:", len(expired), config.QueueMaxOpenAgeSec)
+	for _, cmd := range expired {
+		journal.MarkCommandCancelled(cmd.ID, 0)
+		log.Printf("⌛ Expired queued open: %s %s %.2f lots strat=%s id=%s queued %s", cmd.Symbol, cmd.Side, cmd.Lots, cmd.Strategy, cmd.ID, cmd.enqueuedAt.Format(time.RFC3339))
+		fmt.Fprintf(&b, "\n• %s %s %.2f lot @ %.2f (%s, queued %s)", cmd.Side, cmd.Symbol, cmd.Lots, cmd.Price, cmd.Strategy, cmd.enqueuedAt.Format("2006-01-02 15:04"))
+	}
+	notify(msgError, b.String())
+}
+
+// runServer - ListenAndServe sampai ada SIGINT/SIGTERM, lalu shutdown bertahap
+func runServer(server *http.Server) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatalf("❌ Server failed: %v", err)
+		}
+		return
+	case <-ctx.Done():
+	}
+
+	log.Printf("🛑 Shutdown signal received, draining requests...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️  Server shutdown: %v", err)
+	}
+
+	// Outbox dikosongkan sebelum queue disimpan: OnSent (auto execute) masih bisa enqueue perintah
+	if !flushTelegramOutbox(shutdownTimeout) {
+		log.Printf("⚠️  Telegram outbox not empty before saving queue: %d messages pending", outboxDepth())
+	}
+	queueMu.Lock()
+	queueClosed = true
+	queueMu.Unlock()
+
+	queued := 0
+	if config.QueueStatePath != "off" {
+		n, err := saveQueue(config.QueueStatePath)
+		if err != nil {
+			log.Printf("❌ Failed to persist queue: %v", err)
+		} else if n > 0 {
+			log.Printf("💾 Queue persisted: %d commands → %s", n, config.QueueStatePath)
+		}
+		queued = n
+	}
+
+	offlineMsg := fmt.Sprintf("🛑 Trading System Offline\n🕐 %s\n📦 Pending commands saved: %d",
+		time.Now().Format("2006-01-02 15:04:05"), queued)
+	notify(msgSystem, offlineMsg)
+	if !flushTelegramOutbox(shutdownTimeout) {
+		log.Printf("⚠️  Telegram outbox not empty at shutdown: %d messages dropped", outboxDepth())
+	}
+
+	journal.Close()
+	log.Printf("👋 Shutdown complete")
+}
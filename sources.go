@@ -0,0 +1,643 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============ SIGNAL SOURCES ============
+// Selain EA (/signal), signal bisa datang dari sumber lain: TradingView (/tv), partner
+// lewat HTTP, feed JSON lines lokal, dan nanti channel Telegram / email. Setiap sumber
+// adalah adapter signalSource yang menormalisasi input mentah ke SignalPayload; sesudah
+// auth dan rate limit per sumber, semuanya masuk processSignal yang sama.
+//
+// SIGNAL_SOURCES_FILE berisi daftar sumber (JSON array):
+//
+//	[{"name":"partner","type":"http","token":"${PARTNER_TOKEN}","rate_limit":20,"mapping":{...}},
+//	 {"name":"feed","type":"jsonl","path":"./data/feed.jsonl","rate_limit":60}]
+//
+// Sumber http dilayani di POST /sources/<name>; sumber jsonl membaca baris baru di file.
+// Adapter baru cukup didaftarkan di sourceTypes.
+
+const (
+	sourcePollInterval = time.Second
+	sourceMaxBody      = 64 * 1024
+)
+
+// signalSource - Adapter sumber signal: satu record mentah → SignalPayload.
+// Token di payload (jika ada) dipakai untuk auth bila transport tidak membawa token.
+type signalSource interface {
+	Name() string
+	Normalize(raw []byte) (SignalPayload, error)
+}
+
+// pullSource - Sumber yang membaca sendiri (file, mailbox, channel); Run berjalan di goroutine
+// sendiri dan memanggil ingest untuk setiap record. ingest mengembalikan false jika record
+// kena rate limit: sumber berhenti di record itu dan mencobanya lagi nanti.
+type pullSource interface {
+	signalSource
+	Run(ingest func(raw []byte) bool)
+}
+
+// sourceConfig - Satu entri SIGNAL_SOURCES_FILE
+type sourceConfig struct {
+	Name      string        `json:"name"`
+	Type      string        `json:"type"`       // http | jsonl
+	Token     string        `json:"token"`      // ${ENV} diekspansi; wajib untuk http
+	RateLimit int           `json:"rate_limit"` // signal per menit, 0 = tanpa batas
+	Path      string        `json:"path"`       // jsonl: file yang dibaca
+	FromStart bool          `json:"from_start"` // jsonl: proses isi lama saat start pertama (belum ada offset di journal)
+	Mapping   signalMapping `json:"mapping"`
+}
+
+// sourceTypes - Adapter bawaan per type
+var sourceTypes = map[string]func(c sourceConfig) (signalSource, error){
+	"http":  newHTTPSource,
+	"jsonl": newJSONLSource,
+}
+
+// sourceEntry - Adapter terdaftar beserta auth dan rate limit-nya
+type sourceEntry struct {
+	Source   signalSource
+	Token    string       // kosong = tanpa auth (hanya sumber lokal)
+	Limiter  *rateLimiter // nil = tanpa batas
+	Endpoint string       // path HTTP untuk metrics auth; kosong untuk sumber pull
+}
+
+var signalSources = map[string]*sourceEntry{}
+
+var sourceNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// loadSignalSources - Baca SIGNAL_SOURCES_FILE; error menyebut sumber yang salah
+func loadSignalSources() error {
+	if config.SignalSourcesFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(config.SignalSourcesFile)
+	if err != nil {
+		return err
+	}
+	var configs []sourceConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return fmt.Errorf("%s: %v", config.SignalSourcesFile, err)
+	}
+
+	sources := map[string]*sourceEntry{}
+	for _, c := range configs {
+		c.Name = strings.ToLower(strings.TrimSpace(c.Name))
+		c.Type = strings.ToLower(strings.TrimSpace(c.Type))
+		if !sourceNamePattern.MatchString(c.Name) {
+			return fmt.Errorf("source %q: name must be lowercase letters, digits, - or _", c.Name)
+		}
+		if _, dup := sources[c.Name]; dup {
+			return fmt.Errorf("source %q: duplicate name", c.Name)
+		}
+		build, ok := sourceTypes[c.Type]
+		if !ok {
+			return fmt.Errorf("source %q: unknown type %q (%s)", c.Name, c.Type, strings.Join(sortedKeys(sourceTypes), ", "))
+		}
+		c.Token = os.ExpandEnv(c.Token)
+		src, err := build(c)
+		if err != nil {
+			return fmt.Errorf("source %q: %v", c.Name, err)
+		}
+		entry := &sourceEntry{Source: src, Token: c.Token, Limiter: newRateLimiter(c.RateLimit)}
+		if _, pull := src.(pullSource); !pull {
+			if c.Token == "" {
+				return fmt.Errorf("source %q: token is required for %s sources", c.Name, c.Type)
+			}
+			entry.Endpoint = "/sources/" + c.Name
+		}
+		sources[c.Name] = entry
+	}
+	signalSources = sources
+	return nil
+}
+
+// startSignalSources - Jalankan sumber pull; sumber http cukup dilayani sourcesHandler
+func startSignalSources() {
+	for _, name := range sortedKeys(signalSources) {
+		entry := signalSources[name]
+		pull, ok := entry.Source.(pullSource)
+		if !ok {
+			log.Printf("📡 Signal source %s: POST %s", name, entry.Endpoint)
+			continue
+		}
+		log.Printf("📡 Signal source %s: started", name)
+		go pull.Run(func(raw []byte) bool {
+			rec := &sourceResponse{header: http.Header{}}
+			entry.ingest(rec, raw, "")
+			if rec.status == http.StatusUnauthorized {
+				log.Printf("⚠️ Signal from %s rejected: token mismatch", name)
+			}
+			return rec.status != http.StatusTooManyRequests
+		})
+	}
+}
+
+// ingest - Jalur bersama semua sumber: normalisasi, auth, rate limit, lalu processSignal.
+// token dari transport (query / header); kosong = pakai token di payload.
+func (e *sourceEntry) ingest(w http.ResponseWriter, raw []byte, token string) {
+	name := e.Source.Name()
+	p, err := e.Source.Normalize(raw)
+	if token == "" {
+		token = p.Token
+	}
+	p.Token = ""
+
+	if e.Token != "" && token != e.Token {
+		if e.Endpoint != "" {
+			metricAuthRejected.Inc(e.Endpoint)
+		}
+		metricSourceSignals.Inc(name, "unauthorized")
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, "unauthorized")
+		return
+	}
+	if err != nil {
+		metricSourceSignals.Inc(name, "invalid")
+		log.Printf("❌ Signal from %s rejected: %v", name, err)
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, err.Error())
+		return
+	}
+	// Record invalid tidak memakai kuota rate limit
+	if !e.Limiter.Allow() {
+		metricSourceSignals.Inc(name, "rate_limited")
+		log.Printf("⏳ Signal from %s rate limited: %d/min", name, e.Limiter.limit)
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, "rate limit exceeded")
+		return
+	}
+
+	metricSourceSignals.Inc(name, "accepted")
+	log.Printf("📡 Signal from %s: %s %s @ %.5g strat=%s", name, p.Symbol, p.Side, p.Price, p.Strategy)
+	processSignal(w, p)
+}
+
+// serveSource - POST body sebagai satu record; token di ?token=, header X-API-Token atau payload
+func serveSource(w http.ResponseWriter, r *http.Request, e *sourceEntry) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, sourceMaxBody))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		token = r.Header.Get("X-API-Token")
+	}
+	e.ingest(w, body, token)
+}
+
+// sourcesHandler - POST /sources/<name>
+func sourcesHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/sources/")
+	entry, ok := signalSources[name]
+	if !ok || entry.Endpoint == "" {
+		http.NotFound(w, r)
+		return
+	}
+	serveSource(w, r, entry)
+}
+
+// sourceResponse - ResponseWriter untuk sumber pull (hasil processSignal hanya dicatat di log)
+type sourceResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *sourceResponse) Header() http.Header { return r.header }
+
+func (r *sourceResponse) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *sourceResponse) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+// ============ RATE LIMIT ============
+
+// rateLimiter - Sliding window per menit
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	hits   []time.Time
+}
+
+func newRateLimiter(perMinute int) *rateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &rateLimiter{limit: perMinute, window: time.Minute}
+}
+
+// Allow - nil limiter selalu true
+func (l *rateLimiter) Allow() bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-l.window)
+	i := 0
+	for i < len(l.hits) && !l.hits[i].After(cutoff) {
+		i++
+	}
+	l.hits = l.hits[i:]
+	if len(l.hits) >= l.limit {
+		return false
+	}
+	l.hits = append(l.hits, now)
+	return true
+}
+
+// ============ ADAPTER: MAPPED (HTTP / JSONL) ============
+
+// mappedSource - Normalisasi lewat signalMapping (JSON atau teks template)
+type mappedSource struct {
+	name    string
+	mapping signalMapping
+}
+
+func (s *mappedSource) Name() string { return s.name }
+
+func (s *mappedSource) Normalize(raw []byte) (SignalPayload, error) {
+	values, err := s.mapping.parse(string(raw))
+	if err != nil {
+		return SignalPayload{}, err
+	}
+	return s.mapping.toSignal(values)
+}
+
+// newMappedSource - Mapping default (key sama dengan SignalPayload) ditimpa mapping sumber
+func newMappedSource(c sourceConfig) (*mappedSource, error) {
+	base := defaultSourceMapping
+	base.DefaultStrategy = strings.ToUpper(c.Name)
+	m, err := mergeMapping(base, c.Mapping)
+	if err != nil {
+		return nil, err
+	}
+	return &mappedSource{name: c.Name, mapping: m}, nil
+}
+
+// newHTTPSource - Generic HTTP: body JSON (key bertingkat jadi "a.b") atau teks sesuai templates
+func newHTTPSource(c sourceConfig) (signalSource, error) {
+	return newMappedSource(c)
+}
+
+// jsonlSource - Satu objek JSON per baris; membaca baris yang ditambahkan ke file
+type jsonlSource struct {
+	*mappedSource
+	Path      string
+	FromStart bool
+}
+
+func newJSONLSource(c sourceConfig) (signalSource, error) {
+	if c.Path == "" {
+		return nil, fmt.Errorf("path is required for jsonl sources")
+	}
+	ms, err := newMappedSource(c)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonlSource{mappedSource: ms, Path: c.Path, FromStart: c.FromStart}, nil
+}
+
+// Run - Poll file; baris yang belum diakhiri newline ditunggu. File yang mengecil
+// (truncate / rotate) dibaca ulang dari awal. File yang belum ada dibaca dari awal saat muncul.
+// Offset disimpan di journal setiap batch, jadi restart melanjutkan dari baris terakhir
+// (from_start hanya berlaku jika belum ada offset tersimpan untuk source + path ini).
+// Baris yang kena rate limit menghentikan batch; offset tetap di baris itu sampai diterima.
+func (s *jsonlSource) Run(ingest func(raw []byte) bool) {
+	offset, resumed := journal.SourceOffset(s.name, s.Path)
+	if !resumed {
+		if info, err := os.Stat(s.Path); err == nil && !s.FromStart {
+			offset = info.Size()
+		}
+		journal.SaveSourceOffset(s.name, s.Path, offset)
+	}
+	log.Printf("📄 Signal source %s: following %s from byte %d (resumed=%v)", s.name, s.Path, offset, resumed)
+
+	ticker := time.NewTicker(sourcePollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		info, err := os.Stat(s.Path)
+		if err != nil {
+			continue
+		}
+		if info.Size() < offset {
+			log.Printf("🔄 Signal source %s: %s truncated, reading from start", s.name, s.Path)
+			offset = 0
+		}
+		if info.Size() == offset {
+			continue
+		}
+		data, err := readFrom(s.Path, offset, info.Size()-offset)
+		if err != nil {
+			log.Printf("❌ Signal source %s: %v", s.name, err)
+			continue
+		}
+		end := bytes.LastIndexByte(data, '\n')
+		if end < 0 {
+			continue
+		}
+		start := offset
+		for batch := data[:end+1]; len(batch) > 0; {
+			n := bytes.IndexByte(batch, '\n') + 1
+			if line := bytes.TrimSpace(batch[:n]); len(line) > 0 && !ingest(line) {
+				break
+			}
+			offset += int64(n)
+			batch = batch[n:]
+		}
+		if offset != start {
+			journal.SaveSourceOffset(s.name, s.Path, offset)
+		}
+	}
+}
+
+func readFrom(path string, offset, n int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data := make([]byte, n)
+	read, err := f.ReadAt(data, offset)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data[:read], nil
+}
+
+// ============ SIGNAL MAPPING ============
+
+// signalMapping - Cara membaca record mentah (semua key opsional, default per sumber)
+type signalMapping struct {
+	Templates       []string          `json:"templates"`        // template teks biasa, dicoba berurutan
+	Fields          map[string]string `json:"fields"`           // field SignalPayload → nama placeholder / key JSON
+	Sides           map[string]string `json:"sides"`            // nilai action (lowercase) → BUY / SELL / CLOSE_BUY / CLOSE_SELL
+	Symbols         map[string]string `json:"symbols"`          // ticker sumber → symbol broker
+	DefaultStrategy string            `json:"default_strategy"` // jika record tidak menyebut strategi
+
+	patterns []mappingPattern
+}
+
+type mappingPattern struct {
+	re    *regexp.Regexp
+	names []string
+}
+
+var defaultSides = map[string]string{
+	"buy": "BUY", "long": "BUY",
+	"sell": "SELL", "short": "SELL",
+	"close_buy": "CLOSE_BUY", "exit_long": "CLOSE_BUY", "close_long": "CLOSE_BUY",
+	"close_sell": "CLOSE_SELL", "exit_short": "CLOSE_SELL", "close_short": "CLOSE_SELL",
+}
+
+// defaultSourceMapping - Key record sama dengan JSON /signal
+var defaultSourceMapping = signalMapping{
+	Fields: map[string]string{
+		"symbol":    "symbol",
+		"side":      "side",
+		"price":     "price",
+		"strategy":  "strategy",
+		"timeframe": "timeframe",
+		"atr":       "atr",
+		"reason":    "reason",
+		"token":     "token",
+	},
+	Sides: defaultSides,
+}
+
+var mappingPlaceholder = regexp.MustCompile(`\{\{\s*([\w.]+)\s*\}\}`)
+
+// mergeMapping - base ditimpa custom, lalu template dikompilasi
+func mergeMapping(base, custom signalMapping) (signalMapping, error) {
+	m := base
+	if len(custom.Templates) > 0 {
+		m.Templates = custom.Templates
+	}
+	m.Fields = mergeMap(m.Fields, custom.Fields, strings.ToLower)
+	m.Sides = mergeMap(m.Sides, custom.Sides, strings.ToLower)
+	m.Symbols = mergeMap(m.Symbols, custom.Symbols, strings.ToUpper)
+	m.DefaultStrategy = getOr(custom.DefaultStrategy, m.DefaultStrategy)
+
+	m.patterns = nil
+	for _, t := range m.Templates {
+		p, err := compileMappingTemplate(t)
+		if err != nil {
+			return m, fmt.Errorf("template %q: %v", t, err)
+		}
+		m.patterns = append(m.patterns, p)
+	}
+	return m, nil
+}
+
+// mergeMap - Salinan base ditimpa override (key dinormalisasi)
+func mergeMap(base, override map[string]string, normKey func(string) string) map[string]string {
+	out := map[string]string{}
+	for k, v := range base {
+		out[normKey(k)] = v
+	}
+	for k, v := range override {
+		out[normKey(k)] = v
+	}
+	return out
+}
+
+// compileMappingTemplate - "{{action}} {{ticker}} @ {{close}}" → regex dengan satu grup per placeholder
+func compileMappingTemplate(t string) (mappingPattern, error) {
+	t = strings.TrimSpace(t)
+	var expr strings.Builder
+	var names []string
+	last := 0
+	for _, loc := range mappingPlaceholder.FindAllStringSubmatchIndex(t, -1) {
+		expr.WriteString(literalPattern(t[last:loc[0]]))
+		expr.WriteString(`(.+?)`)
+		names = append(names, strings.ToLower(t[loc[2]:loc[3]]))
+		last = loc[1]
+	}
+	expr.WriteString(literalPattern(t[last:]))
+	if len(names) == 0 {
+		return mappingPattern{}, fmt.Errorf("no {{placeholder}}")
+	}
+	re, err := regexp.Compile(`(?is)^\s*` + expr.String() + `\s*$`)
+	return mappingPattern{re: re, names: names}, err
+}
+
+// literalPattern - Teks literal di antara placeholder; spasi cocok dengan satu spasi atau lebih
+func literalPattern(literal string) string {
+	if literal == "" {
+		return ""
+	}
+	parts := strings.Fields(literal)
+	if len(parts) == 0 {
+		return `\s+`
+	}
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	pattern := strings.Join(parts, `\s+`)
+	if strings.TrimLeft(literal, " \t\r\n") != literal {
+		pattern = `\s+` + pattern
+	}
+	if strings.TrimRight(literal, " \t\r\n") != literal {
+		pattern += `\s+`
+	}
+	return pattern
+}
+
+// parse - Record → nilai per placeholder / key (key lowercase; objek bertingkat jadi "a.b")
+func (m *signalMapping) parse(body string) (map[string]string, error) {
+	body = strings.TrimSpace(body)
+	values := map[string]string{}
+
+	if strings.HasPrefix(body, "{") {
+		var raw map[string]interface{}
+		if err := json.Unmarshal([]byte(body), &raw); err != nil {
+			return nil, fmt.Errorf("invalid json: %v", err)
+		}
+		flattenJSON("", raw, values)
+		return values, nil
+	}
+
+	for _, p := range m.patterns {
+		match := p.re.FindStringSubmatch(body)
+		if match == nil {
+			continue
+		}
+		for i, name := range p.names {
+			values[name] = strings.TrimSpace(match[i+1])
+		}
+		return values, nil
+	}
+	if len(m.patterns) == 0 {
+		return nil, fmt.Errorf("body is not JSON and no text templates are configured")
+	}
+	return nil, fmt.Errorf("text matches no template (%s)", strings.Join(m.Templates, " | "))
+}
+
+// flattenJSON - {"data":{"pair":"X"}} → values["data.pair"] = "X"
+func flattenJSON(prefix string, raw map[string]interface{}, values map[string]string) {
+	for k, v := range raw {
+		key := strings.ToLower(prefix + k)
+		switch val := v.(type) {
+		case string:
+			values[key] = val
+		case float64:
+			values[key] = strconv.FormatFloat(val, 'f', -1, 64)
+		case map[string]interface{}:
+			flattenJSON(key+".", val, values)
+		case nil:
+		default:
+			values[key] = fmt.Sprint(val)
+		}
+	}
+}
+
+// mapSymbol - "OANDA:XAUUSD" → rename penuh, lalu tanpa prefix exchange, lalu apa adanya
+func (m *signalMapping) mapSymbol(ticker string) string {
+	ticker = strings.ToUpper(strings.TrimSpace(ticker))
+	if s, ok := m.Symbols[ticker]; ok {
+		return s
+	}
+	if i := strings.LastIndex(ticker, ":"); i >= 0 {
+		ticker = ticker[i+1:]
+	}
+	if s, ok := m.Symbols[ticker]; ok {
+		return s
+	}
+	return ticker
+}
+
+// parseInterval - Timeframe ("15", "60", "1D", "W") → menit
+func parseInterval(interval string) int {
+	interval = strings.ToUpper(strings.TrimSpace(interval))
+	if n, err := strconv.Atoi(interval); err == nil {
+		return n
+	}
+	units := map[byte]int{'S': 0, 'D': 1440, 'W': 10080, 'M': 43200}
+	if interval == "" {
+		return 0
+	}
+	mult, ok := units[interval[len(interval)-1]]
+	if !ok {
+		return 0
+	}
+	n := 1
+	if len(interval) > 1 {
+		var err error
+		if n, err = strconv.Atoi(interval[:len(interval)-1]); err != nil {
+			return 0
+		}
+	}
+	return n * mult
+}
+
+// toSignal - Nilai record → SignalPayload sesuai mapping. Token selalu diisi (untuk auth)
+// walau record ditolak.
+func (m *signalMapping) toSignal(values map[string]string) (SignalPayload, error) {
+	field := func(name string) string {
+		return values[strings.ToLower(m.Fields[name])]
+	}
+
+	p := SignalPayload{
+		Token:     field("token"),
+		Symbol:    m.mapSymbol(field("symbol")),
+		Strategy:  strings.ToUpper(getOr(field("strategy"), m.DefaultStrategy)),
+		Timeframe: parseInterval(field("timeframe")),
+		Reason:    field("reason"),
+		Timestamp: time.Now().Unix(),
+	}
+	if p.Symbol == "" {
+		return p, fmt.Errorf("missing symbol (%s)", m.Fields["symbol"])
+	}
+
+	action := strings.ToLower(strings.TrimSpace(field("side")))
+	side, ok := m.Sides[action]
+	if !ok {
+		return p, fmt.Errorf("unknown action %q (%s)", action, strings.Join(sortedKeys(m.Sides), ", "))
+	}
+	p.Side = strings.ToUpper(side)
+
+	price, err := strconv.ParseFloat(field("price"), 64)
+	if err != nil || price <= 0 {
+		return p, fmt.Errorf("invalid price %q (%s)", field("price"), m.Fields["price"])
+	}
+	p.Price = price
+	if atr := field("atr"); atr != "" {
+		p.ATR, _ = strconv.ParseFloat(atr, 64)
+	}
+	if strings.HasPrefix(p.Side, "CLOSE_") {
+		p.Strategy = "CLOSE_" + strings.TrimPrefix(p.Strategy, "CLOSE_") // tanpa ticket: EA menutup per symbol + strategi
+	}
+	if t := signalType(p); t != "open" && t != "close" {
+		return p, fmt.Errorf("strategy %s is reserved for the EA", p.Strategy)
+	}
+	return p, nil
+}
@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============ STRATEGY PERFORMANCE ============
+// Statistik dari trade yang sudah close (ORDER_CLOSED_CONFIRMATION) di journal.
+// R multiple dihitung dari jarak harga: (close - open) / (open - SL), jadi hanya
+// trade yang punya command dengan SL yang masuk perhitungan Avg R.
+
+type PerformanceStats struct {
+	Key            string   `json:"key"`
+	Trades         int      `json:"trades"`
+	Wins           int      `json:"wins"`
+	Losses         int      `json:"losses"`
+	WinRate        float64  `json:"win_rate"`
+	NetProfit      float64  `json:"net_profit"`
+	GrossProfit    float64  `json:"gross_profit"`
+	GrossLoss      float64  `json:"gross_loss"`
+	ProfitFactor   *float64 `json:"profit_factor"` // null jika belum ada loss
+	Expectancy     float64  `json:"expectancy"`
+	AvgR           float64  `json:"avg_r"`
+	RTrades        int      `json:"r_trades"`
+	MaxDrawdown    float64  `json:"max_drawdown"`
+	Signals        int      `json:"signals"`
+	Executed       int      `json:"executed"`
+	ExecutionRatio float64  `json:"execution_ratio"`
+
+	sumR, equity, peak float64
+}
+
+type StatsReport struct {
+	From       int64              `json:"from"`
+	To         int64              `json:"to"`
+	Overall    PerformanceStats   `json:"overall"`
+	ByStrategy []PerformanceStats `json:"by_strategy"`
+	BySymbol   []PerformanceStats `json:"by_symbol"`
+}
+
+type closedTrade struct {
+	Ticket     int64
+	Symbol     string
+	Side       string
+	Strategy   string
+	OpenPrice  float64
+	ClosePrice float64
+	SL         float64
+	Profit     float64
+	ClosedAt   int64
+}
+
+type signalOutcome struct {
+	Symbol   string
+	Strategy string
+	Executed bool
+}
+
+// ClosedTrades - Trade yang close di rentang waktu, urut berdasarkan waktu close
+func (j *Journal) ClosedTrades(from, to int64) ([]closedTrade, error) {
+	rows, err := j.db.Query(
+		`SELECT t.ticket, COALESCE(t.symbol, ''), COALESCE(t.side, ''), COALESCE(NULLIF(t.strategy, ''), 'UNKNOWN'),
+		        COALESCE(t.open_price, 0), COALESCE(t.close_price, 0), COALESCE(c.sl, 0), COALESCE(t.profit, 0), t.closed_at
+		 FROM trades t LEFT JOIN commands c ON c.id = t.command_id
+		 WHERE t.closed_at IS NOT NULL AND t.closed_at >= ? AND t.closed_at < ?
+		 ORDER BY t.closed_at, t.ticket`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var trades []closedTrade
+	for rows.Next() {
+		var t closedTrade
+		if err := rows.Scan(&t.Ticket, &t.Symbol, &t.Side, &t.Strategy, &t.OpenPrice, &t.ClosePrice, &t.SL, &t.Profit, &t.ClosedAt); err != nil {
+			return nil, err
+		}
+		trades = append(trades, t)
+	}
+	return trades, rows.Err()
+}
+
+// SignalOutcomes - Open signal di rentang waktu dan apakah dieksekusi (manual atau auto)
+func (j *Journal) SignalOutcomes(from, to int64) ([]signalOutcome, error) {
+	rows, err := j.db.Query(
+		`SELECT COALESCE(symbol, ''), COALESCE(strategy, ''), status IN (?, ?)
+		 FROM signals
+		 WHERE received_at >= ? AND received_at < ? AND side NOT LIKE 'CLOSE_%'`,
+		signalExecuted, signalAuto, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var outcomes []signalOutcome
+	for rows.Next() {
+		var o signalOutcome
+		if err := rows.Scan(&o.Symbol, &o.Strategy, &o.Executed); err != nil {
+			return nil, err
+		}
+		outcomes = append(outcomes, o)
+	}
+	return outcomes, rows.Err()
+}
+
+// rMultiple - Hasil trade dalam kelipatan risiko awal (0, false jika SL tidak diketahui)
+func (t closedTrade) rMultiple() (float64, bool) {
+	risk := math.Abs(t.OpenPrice - t.SL)
+	if t.SL <= 0 || risk == 0 || t.OpenPrice == 0 {
+		return 0, false
+	}
+	move := t.ClosePrice - t.OpenPrice
+	if strings.EqualFold(t.Side, "SELL") {
+		move = -move
+	}
+	return move / risk, true
+}
+
+func (s *PerformanceStats) addTrade(t closedTrade) {
+	s.Trades++
+	s.NetProfit += t.Profit
+	if t.Profit > 0 {
+		s.Wins++
+		s.GrossProfit += t.Profit
+	} else if t.Profit < 0 {
+		s.Losses++
+		s.GrossLoss += -t.Profit
+	}
+	if r, ok := t.rMultiple(); ok {
+		s.sumR += r
+		s.RTrades++
+	}
+
+	// Drawdown dari kurva equity kumulatif
+	s.equity += t.Profit
+	if s.equity > s.peak {
+		s.peak = s.equity
+	}
+	if dd := s.peak - s.equity; dd > s.MaxDrawdown {
+		s.MaxDrawdown = dd
+	}
+}
+
+func (s *PerformanceStats) finalize() {
+	if s.Trades > 0 {
+		s.WinRate = float64(s.Wins) / float64(s.Trades)
+		s.Expectancy = s.NetProfit / float64(s.Trades)
+	}
+	if s.GrossLoss > 0 {
+		pf := s.GrossProfit / s.GrossLoss
+		s.ProfitFactor = &pf
+	}
+	if s.RTrades > 0 {
+		s.AvgR = s.sumR / float64(s.RTrades)
+	}
+	if s.Signals > 0 {
+		s.ExecutionRatio = float64(s.Executed) / float64(s.Signals)
+	}
+}
+
+// BuildStatsReport - Hitung statistik per strategi dan per symbol
+func (j *Journal) BuildStatsReport(from, to time.Time) (*StatsReport, error) {
+	trades, err := j.ClosedTrades(from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+	outcomes, err := j.SignalOutcomes(from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	overall := &PerformanceStats{Key: "ALL"}
+	byStrategy := map[string]*PerformanceStats{}
+	bySymbol := map[string]*PerformanceStats{}
+	get := func(m map[string]*PerformanceStats, key string) *PerformanceStats {
+		if m[key] == nil {
+			m[key] = &PerformanceStats{Key: key}
+		}
+		return m[key]
+	}
+
+	for _, t := range trades {
+		overall.addTrade(t)
+		get(byStrategy, t.Strategy).addTrade(t)
+		get(bySymbol, t.Symbol).addTrade(t)
+	}
+	for _, o := range outcomes {
+		for _, s := range []*PerformanceStats{overall, get(byStrategy, o.Strategy), get(bySymbol, o.Symbol)} {
+			s.Signals++
+			if o.Executed {
+				s.Executed++
+			}
+		}
+	}
+
+	report := &StatsReport{From: from.Unix(), To: to.Unix()}
+	overall.finalize()
+	report.Overall = *overall
+	report.ByStrategy = sortedStats(byStrategy)
+	report.BySymbol = sortedStats(bySymbol)
+	return report, nil
+}
+
+// sortedStats - Urutkan berdasarkan net profit (terbesar dulu)
+func sortedStats(m map[string]*PerformanceStats) []PerformanceStats {
+	list := make([]PerformanceStats, 0, len(m))
+	for _, s := range m {
+		s.finalize()
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, k int) bool {
+		if list[i].NetProfit != list[k].NetProfit {
+			return list[i].NetProfit > list[k].NetProfit
+		}
+		return list[i].Key < list[k].Key
+	})
+	return list
+}
+
+// ============ TELEGRAM: /stats ============
+// /stats [hari] - default 30 hari terakhir
+func handleStatsCommand(chatID int64, args []string) {
+	if journal == nil {
+		sendTelegramTo(chatID, "⚠️ Journal disabled, statistics unavailable")
+		return
+	}
+
+	days := 30
+	if len(args) > 0 {
+		if d, err := strconv.Atoi(args[0]); err == nil && d > 0 {
+			days = d
+		}
+	}
+
+	to := time.Now()
+	report, err := journal.BuildStatsReport(to.AddDate(0, 0, -days), to)
+	if err != nil {
+		log.Printf("❌ stats error: %v", err)
+		sendTelegramTo(chatID, "❌ Failed to compute statistics")
+		return
+	}
+	sendTelegramTo(chatID, formatStatsReport(report, days))
+}
+
+func formatStatsReport(report *StatsReport, days int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 Strategy Performance (%d hari)\n", days)
+	fmt.Fprintf(&b, "🧮 %s\n", formatStatsLine(report.Overall))
+
+	if len(report.ByStrategy) > 0 {
+		b.WriteString("\n🎯 By Strategy\n")
+		for _, s := range report.ByStrategy {
+			fmt.Fprintf(&b, "• %s: %s\n", s.Key, formatStatsLine(s))
+		}
+	}
+	if len(report.BySymbol) > 0 {
+		b.WriteString("\n💱 By Symbol\n")
+		for _, s := range report.BySymbol {
+			fmt.Fprintf(&b, "• %s: %s\n", s.Key, formatStatsLine(s))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func formatStatsLine(s PerformanceStats) string {
+	pf := "∞"
+	if s.ProfitFactor != nil {
+		pf = fmt.Sprintf("%.2f", *s.ProfitFactor)
+	} else if s.Trades == 0 || s.GrossProfit == 0 {
+		pf = "-"
+	}
+	return fmt.Sprintf("%d tr | WR %.0f%% | PF %s | Avg R %.2f | Exp %+.2f | Net %+.2f | DD %.2f | Exec %d/%d",
+		s.Trades, s.WinRate*100, pf, s.AvgR, s.Expectancy, s.NetProfit, s.MaxDrawdown, s.Executed, s.Signals)
+}
+
+// ============ HTTP: STATS ============
+// GET /stats?token=...&days=30  atau  &from=2026-01-01&to=2026-02-01
+func statsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !authorizeAPIRequest(w, r) {
+		return
+	}
+
+	if journal == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("journal disabled"))
+		return
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+	if d, err := strconv.Atoi(r.URL.Query().Get("days")); err == nil && d > 0 {
+		from = to.AddDate(0, 0, -d)
+	}
+	// Tanggal YYYY-MM-DD dalam reportLocation() seperti /export; "to" inklusif
+	if v := r.URL.Query().Get("from"); v != "" {
+		f, err := parseExportDate(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid from date"))
+			return
+		}
+		from = f
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := parseExportDate(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid to date"))
+			return
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+
+	report, err := journal.BuildStatsReport(from, to)
+	if err != nil {
+		log.Printf("❌ stats error: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// ============ MESSAGE TEMPLATES ============
+// Semua pesan signal / konfirmasi / status dirender dari text/template di TEMPLATES_DIR
+// (default bawaan di-embed dari templates/*.tmpl; file di TEMPLATES_DIR menimpanya).
+// Override per bahasa: nama.<lang>.tmpl (mis. open_signal.en.tmpl).
+// Template tidak perlu escape: hasil render di-escape utuh sesuai TELEGRAM_PARSE_MODE
+// (kosong | HTML | MarkdownV2), format lewat {{bold}} / {{italic}} / {{code}}.
+// File dicek setiap 5 detik dan dimuat ulang jika berubah.
+
+const (
+	parseModeHTML     = "HTML"
+	parseModeMarkdown = "MarkdownV2"
+	parseModeDiscord  = "discord" // markdown Discord (notifier webhook, bukan parse_mode Telegram)
+	parseModeSlack    = "slack"   // mrkdwn Slack
+
+	templateReloadInterval = 5 * time.Second
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplateFS embed.FS
+
+// Penanda format (private use area) yang tidak tersentuh escape, diganti setelahnya
+const (
+	markOpen  = "\uE000"
+	markClose = "\uE001"
+)
+
+// messageData - Data untuk template: field SignalPayload (.Symbol, .Side, .Price, .Ref1, ...) + tambahan
+type messageData struct {
+	SignalPayload
+	Lang      string
+	Time      string
+	Note      string // alasan blackout/offline, error auto execute, reason close signal, strategi asal
+	Buttons   bool   // keyboard eksekusi ikut dikirim
+	CloseSide string
+	Lots      float64
+	SL        float64
+	TP        float64
+	Profit    float64
+	HasPL     bool
+	Currency  string
+	Emoji     string
+
+	// ACCOUNT_INFO
+	Balance, Equity, Margin, FreeMargin, Leverage string
+}
+
+// T - Teks dari katalog bahasa pesan ini: {{.T "hint.pick_lot"}}
+func (d messageData) T(key string, args ...interface{}) string {
+	return T(d.Lang, key, args...)
+}
+
+var templateFuncs = template.FuncMap{
+	"bold":   func(s interface{}) string { return markOpen + "b" + fmt.Sprint(s) + markClose + "b" },
+	"italic": func(s interface{}) string { return markOpen + "i" + fmt.Sprint(s) + markClose + "i" },
+	"code":   func(s interface{}) string { return markOpen + "c" + fmt.Sprint(s) + markClose + "c" },
+	"signed": func(v float64) string {
+		if v > 0 {
+			return fmt.Sprintf("+%.2f", v)
+		}
+		return fmt.Sprintf("%.2f", v)
+	},
+	"upper": strings.ToUpper,
+}
+
+var (
+	templatesMu    sync.RWMutex
+	templates      *template.Template
+	templatesStamp time.Time
+)
+
+// loadTemplates - Template bawaan lalu override dari TEMPLATES_DIR; set lama dipertahankan jika ada error
+func loadTemplates() error {
+	set, err := template.New("messages").Funcs(templateFuncs).ParseFS(defaultTemplateFS, "templates/*.tmpl")
+	if err != nil {
+		return fmt.Errorf("default templates: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(config.TemplatesDir, "*.tmpl"))
+	if len(files) > 0 {
+		if set, err = set.ParseFiles(files...); err != nil {
+			return err
+		}
+	}
+
+	templatesMu.Lock()
+	templates = set
+	templatesStamp = latestTemplateChange()
+	templatesMu.Unlock()
+	return nil
+}
+
+// latestTemplateChange - mtime terbaru di TEMPLATES_DIR (zero jika tidak ada)
+func latestTemplateChange() time.Time {
+	var latest time.Time
+	files, _ := filepath.Glob(filepath.Join(config.TemplatesDir, "*.tmpl"))
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest
+}
+
+// startTemplateWatcher - Hot reload saat file template berubah
+func startTemplateWatcher() {
+	ticker := time.NewTicker(templateReloadInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		templatesMu.RLock()
+		stamp := templatesStamp
+		templatesMu.RUnlock()
+		if latestTemplateChange().Equal(stamp) {
+			continue
+		}
+		if err := loadTemplates(); err != nil {
+			log.Printf("❌ Template reload failed (keeping previous set): %v", err)
+			templatesMu.Lock()
+			templatesStamp = latestTemplateChange() // jangan ulangi error yang sama setiap tick
+			templatesMu.Unlock()
+			continue
+		}
+		log.Printf("🔄 Message templates reloaded from %s", config.TemplatesDir)
+	}
+}
+
+// templateNames - Nama template pesan (tanpa partial _*.tmpl dan override bahasa)
+func templateNames() []string {
+	templatesMu.RLock()
+	defer templatesMu.RUnlock()
+	var names []string
+	for _, t := range templates.Templates() {
+		name := t.Name()
+		if !strings.HasSuffix(name, ".tmpl") || strings.HasPrefix(name, "_") || strings.Count(name, ".") > 1 {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(name, ".tmpl"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// renderMessage - Render template lalu escape untuk parse mode tujuan
+func renderMessage(name string, data messageData, mode string) (string, error) {
+	templatesMu.RLock()
+	set := templates
+	templatesMu.RUnlock()
+	if set == nil {
+		return "", fmt.Errorf("templates not loaded")
+	}
+
+	t := set.Lookup(name + "." + data.Lang + ".tmpl")
+	if t == nil {
+		t = set.Lookup(name + ".tmpl")
+	}
+	if t == nil {
+		return "", fmt.Errorf("template %q not found", name)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return formatMarkup(mode, strings.TrimSpace(buf.String())), nil
+}
+
+// mustRenderMessage - Seperti renderMessage; jika gagal, pesan minimal agar signal tetap terkirim
+func mustRenderMessage(name string, data messageData, mode string) string {
+	msg, err := renderMessage(name, data, mode)
+	if err != nil {
+		log.Printf("❌ Template %s: %v", name, err)
+		return escapeMarkup(mode, fmt.Sprintf("[%s] %s %s %s @ %.2f\n🕐 %s", name, data.Symbol, data.Side, data.Strategy, data.Price, data.Time))
+	}
+	return msg
+}
+
+// normalizeParseMode - "html" → HTML, "markdown"/"markdownv2" → MarkdownV2, selain itu teks biasa
+func normalizeParseMode(mode string) string {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "html":
+		return parseModeHTML
+	case "markdown", "markdownv2":
+		return parseModeMarkdown
+	default:
+		return ""
+	}
+}
+
+// message - Isi notifikasi yang dirender per tujuan: template + data, atau teks biasa
+type message struct {
+	Template string
+	Data     messageData
+	Text     string // dipakai jika Template kosong
+}
+
+func templateMessage(name string, data messageData) message {
+	return message{Template: name, Data: data}
+}
+
+// withButtons - Salinan pesan untuk tujuan dengan / tanpa keyboard eksekusi
+func (m message) withButtons(on bool) message {
+	if m.Template != "" {
+		m.Data.Buttons = on
+	}
+	return m
+}
+
+func plainMessage(text string) message {
+	return message{Text: text}
+}
+
+// render - Teks untuk parse mode tujuan (teks biasa cukup di-escape)
+func (m message) render(mode string) string {
+	if m.Template == "" {
+		return escapeMarkup(mode, m.Text)
+	}
+	return mustRenderMessage(m.Template, m.Data, mode)
+}
+
+// escapeMarkup - Escape teks biasa untuk parse mode Telegram
+func escapeMarkup(mode, s string) string {
+	switch mode {
+	case parseModeHTML, parseModeSlack:
+		return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+	case parseModeMarkdown, parseModeDiscord:
+		special := "\\_*[]()~`>#+-=|{}.!"
+		if mode == parseModeDiscord {
+			special = "\\_*~`|>"
+		}
+		var b strings.Builder
+		for _, r := range s {
+			if strings.ContainsRune(special, r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	default:
+		return s
+	}
+}
+
+// formatMarkup - Escape hasil render lalu ganti penanda bold/italic/code dengan markup parse mode
+func formatMarkup(mode, s string) string {
+	tags := map[string][2]string{}
+	switch mode {
+	case parseModeHTML:
+		tags = map[string][2]string{"b": {"<b>", "</b>"}, "i": {"<i>", "</i>"}, "c": {"<code>", "</code>"}}
+	case parseModeMarkdown, parseModeSlack:
+		tags = map[string][2]string{"b": {"*", "*"}, "i": {"_", "_"}, "c": {"`", "`"}}
+	case parseModeDiscord:
+		tags = map[string][2]string{"b": {"**", "**"}, "i": {"*", "*"}, "c": {"`", "`"}}
+	}
+
+	var pairs []string
+	for _, kind := range []string{"b", "i", "c"} {
+		pairs = append(pairs, markOpen+kind, tags[kind][0], markClose+kind, tags[kind][1])
+	}
+	return strings.NewReplacer(pairs...).Replace(escapeMarkup(mode, s))
+}
+
+// ============ TELEGRAM: /preview ============
+
+// sampleMessageData - Contoh SignalPayload untuk preview template
+func sampleMessageData(lang string) messageData {
+	prefs := chatPrefs()
+	return messageData{
+		SignalPayload: SignalPayload{
+			Symbol: "XAUUSD", Side: "BUY", Strategy: "EMA_PULLBACK", Price: 2650.45,
+			Ref1: 2642.10, Ref2: 123456, ATR: 3.2, Reason: "#123456 XAUUSD BUY 0.10 @ 2642.10", Account: "5012345",
+		},
+		Lang:      lang,
+		Time:      time.Now().In(prefs.location()).Format("15:04:05 MST"),
+		Note:      "News blackout: USD NFP (30 min)",
+		Buttons:   true,
+		CloseSide: "BUY",
+		Lots:      0.10,
+		SL:        2645.65,
+		TP:        2660.05,
+		Profit:    83.50,
+		HasPL:     true,
+		Currency:  "USD",
+		Emoji:     "✅",
+		Balance:   "10000.00", Equity: "10083.50", Margin: "265.05", FreeMargin: "9818.45", Leverage: "500",
+	}
+}
+
+// handlePreviewCommand - /preview [template] [lang]: render template dengan contoh signal
+func handlePreviewCommand(from TelegramUser, chatID int64, args []string) {
+	if len(args) == 0 {
+		sendTelegramTo(chatID, "🧩 Templates: "+strings.Join(templateNames(), ", ")+"\nUsage: /preview <template> [id|en]")
+		return
+	}
+
+	lang := getUserPrefs(from.ID).Language
+	if len(args) > 1 {
+		if l := normalizeLanguage(args[1]); l != "" {
+			lang = l
+		}
+	}
+	msg, err := renderMessage(args[0], sampleMessageData(lang), config.ParseMode)
+	if err != nil {
+		sendTelegramTo(chatID, "❌ "+err.Error())
+		return
+	}
+	if _, err := sendTelegramMessage(strconv.FormatInt(chatID, 10), msg, config.ParseMode, nil); err != nil {
+		sendTelegramTo(chatID, fmt.Sprintf("❌ Telegram rejected %s (parse mode %q): %v", args[0], config.ParseMode, err))
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+// ============ TRADINGVIEW ALERTS ============
+// POST /tv?token=... menerima webhook alert TradingView. Pesan alert boleh JSON
+// ({"ticker":"{{ticker}}","close":{{close}},"action":"{{strategy.order.action}}"})
+// atau teks biasa yang cocok dengan salah satu template di TV_MAPPING_FILE
+// ("{{strategy.order.action}} {{ticker}} @ {{close}}"). TradingView adalah signal
+// source dengan mapping sendiri (lihat sources.go) dan diproses seperti signal dari EA.
+
+var defaultTVMapping = signalMapping{
+	Templates: []string{
+		"{{action}} {{ticker}} @ {{close}}",
+		"{{action}} {{ticker}} {{close}}",
+	},
+	Fields: map[string]string{
+		"symbol":    "ticker",
+		"side":      "action",
+		"price":     "close",
+		"strategy":  "strategy",
+		"timeframe": "interval",
+		"atr":       "atr",
+		"reason":    "comment",
+		"token":     "token",
+	},
+	Sides:           defaultSides,
+	DefaultStrategy: "TRADINGVIEW",
+}
+
+var tvSource *sourceEntry
+
+// loadTVMapping - Default + override dari TV_MAPPING_FILE (isi sama dengan "mapping" di SIGNAL_SOURCES_FILE)
+func loadTVMapping() error {
+	var custom signalMapping
+	if config.TVMappingFile != "" {
+		data, err := os.ReadFile(config.TVMappingFile)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &custom); err != nil {
+			return fmt.Errorf("%s: %v", config.TVMappingFile, err)
+		}
+	}
+	m, err := mergeMapping(defaultTVMapping, custom)
+	if err != nil {
+		return err
+	}
+	tvSource = &sourceEntry{
+		Source:   &mappedSource{name: "tradingview", mapping: m},
+		Token:    config.TVToken,
+		Limiter:  newRateLimiter(config.TVRateLimit),
+		Endpoint: "/tv",
+	}
+	log.Printf("📺 TradingView mapping: %d template(s), %d symbol rename(s)", len(m.patterns), len(m.Symbols))
+	return nil
+}
+
+// tvHandler - POST /tv?token=TV_TOKEN (atau field "token" di alert JSON)
+func tvHandler(w http.ResponseWriter, r *http.Request) {
+	serveSource(w, r, tvSource)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCompileMappingTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		input    string
+		want     map[string]string // nil = tidak cocok
+		wantErr  string
+	}{
+		{
+			name:     "action ticker price",
+			template: "{{action}} {{ticker}} @ {{close}}",
+			input:    "buy OANDA:XAUUSD @ 2650.45",
+			want:     map[string]string{"action": "buy", "ticker": "OANDA:XAUUSD", "close": "2650.45"},
+		},
+		{
+			name:     "whitespace and case are loose",
+			template: " {{ Action }}  {{ticker}} @ {{close}} ",
+			input:    "\n SELL   EURUSD\t@  1.0850 \n",
+			want:     map[string]string{"action": "SELL", "ticker": "EURUSD", "close": "1.0850"},
+		},
+		{
+			name:     "dotted names and regex characters are literal",
+			template: "[{{strategy.order.action}}] {{ticker}} (tf={{interval}}) price=${{close}}",
+			input:    "[buy] XAUUSD (tf=15) price=$2650.4",
+			want:     map[string]string{"strategy.order.action": "buy", "ticker": "XAUUSD", "interval": "15", "close": "2650.4"},
+		},
+		{
+			name:     "literal text must match",
+			template: "{{action}} {{ticker}} @ {{close}}",
+			input:    "buy XAUUSD at 2650",
+		},
+		{
+			name:     "whole body must match",
+			template: "{{action}} {{ticker}}",
+			input:    "buy",
+		},
+		{name: "no placeholder", template: "buy gold", wantErr: "no {{placeholder}}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := compileMappingTemplate(tt.template)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("compileMappingTemplate: %v", err)
+			}
+			match := p.re.FindStringSubmatch(tt.input)
+			if tt.want == nil {
+				if match != nil {
+					t.Fatalf("%q matched %q: %q", tt.template, tt.input, match)
+				}
+				return
+			}
+			if match == nil {
+				t.Fatalf("%q did not match %q (%s)", tt.template, tt.input, p.re)
+			}
+			got := map[string]string{}
+			for i, name := range p.names {
+				got[name] = strings.TrimSpace(match[i+1])
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("values = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTVMappingToSignal(t *testing.T) {
+	m, err := mergeMapping(defaultTVMapping, signalMapping{
+		Symbols: map[string]string{"gold": "XAUUSD", "FX:EURUSD": "EURUSD.m"},
+		Sides:   map[string]string{"Flat": "close_buy"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		want    SignalPayload // Token, Symbol, Side, Price, Strategy, Timeframe, ATR, Reason
+		wantErr string
+	}{
+		{
+			name: "json alert",
+			body: `{"ticker":"OANDA:XAUUSD","action":"buy","close":2650.45,"strategy":"ema_pullback","interval":"15","atr":3.2,"comment":"x","token":"t0k"}`,
+			want: SignalPayload{Token: "t0k", Symbol: "XAUUSD", Side: "BUY", Price: 2650.45, Strategy: "EMA_PULLBACK", Timeframe: 15, ATR: 3.2, Reason: "x"},
+		},
+		{
+			name: "text alert with default strategy",
+			body: "short GOLD @ 2650.1",
+			want: SignalPayload{Symbol: "XAUUSD", Side: "SELL", Price: 2650.1, Strategy: "TRADINGVIEW"},
+		},
+		{
+			name: "full ticker rename and daily interval",
+			body: `{"ticker":"FX:EURUSD","action":"long","close":"1.085","interval":"1D"}`,
+			want: SignalPayload{Symbol: "EURUSD.m", Side: "BUY", Price: 1.085, Strategy: "TRADINGVIEW", Timeframe: 1440},
+		},
+		{
+			name: "close action prefixes strategy",
+			body: `{"ticker":"XAUUSD","action":"FLAT","close":2651,"strategy":"breakout"}`,
+			want: SignalPayload{Symbol: "XAUUSD", Side: "CLOSE_BUY", Price: 2651, Strategy: "CLOSE_BREAKOUT"},
+		},
+		{name: "unknown action", body: "hold XAUUSD 2650", wantErr: `unknown action "hold"`},
+		{name: "invalid price", body: `{"ticker":"XAUUSD","action":"buy","close":"n/a"}`, wantErr: `invalid price "n/a"`},
+		{name: "missing symbol", body: `{"action":"buy","close":1}`, wantErr: "missing symbol (ticker)"},
+		{name: "reserved strategy", body: `{"ticker":"XAUUSD","action":"buy","close":1,"strategy":"account_info"}`, wantErr: "reserved for the EA"},
+		{name: "no template", body: "buy XAUUSD", wantErr: "text matches no template"},
+		{name: "bad json", body: `{"ticker":`, wantErr: "invalid json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := m.parse(tt.body)
+			var p SignalPayload
+			if err == nil {
+				p, err = m.toSignal(values)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("toSignal: %v", err)
+			}
+			if p.Timestamp == 0 {
+				t.Error("timestamp not set")
+			}
+			p.Timestamp = 0
+			if p != tt.want {
+				t.Errorf("payload = %+v\nwant      %+v", p, tt.want)
+			}
+		})
+	}
+}